@@ -1,4 +1,5 @@
 // calls testing_buildBlockV1 with an unapplicable transaction (wrong nonce); client MUST return an error and not modify the chain
+// validparams: request parameters are valid, the request fails for other reasons.
 >> {"jsonrpc":"2.0","id":1,"method":"testing_buildBlockV1","params":["0xd226371d0b1551adb03fb52b71f08e3e11247fe9b1af994768af8cdaa8e7dcd7",{"parentBeaconBlockRoot":"0xcf8e0d4e9587369b2301d0790347320302cc0943d5a1884365149a42212e8822","prevRandao":"0x0000000000000000000000000000000000000000000000000000000000000000","suggestedFeeRecipient":"0x0000000000000000000000000000000000000000","timestamp":"0x228","withdrawals":[]},["0x02f873870c72dd9d5e883e8203e78201f48401a2158b825208947dcd17433742f4c0ca53122ab541d0ba67fc27df8203e880c001a0c46b4838526f9ce894679e8b0a5dcf7ed1bacdf44669c76a26d22433666ca8c2a0773ae609f21ada5da2d7eeccca9a49cf8199dedb9ad69ce92cf8a5a4b5a415a2"],"0x"]}
 << {"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too high: address 0x16c57eDF7Fa9D9525378B0b81Bf8A3cEd0620C1c, tx: 999 state: 0"}}
 >> {"jsonrpc":"2.0","id":2,"method":"eth_getBlockByNumber","params":["latest",true]}
@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -29,13 +32,20 @@ type Client interface {
 	Close() error
 }
 
-// gethClient is a wrapper around a go-ethereum instance on a separate thread.
-type gethClient struct {
+// execClient is a wrapper around a client binary running in a separate process.
+// Its driver gives the commands to initialize the client and the options to
+// start it.
+type execClient struct {
+	driver  *execDriver
+	cfg     *clientConfig
 	cmd     *exec.Cmd
-	path    string
 	workdir string
+	chain   string
 	jwt     []byte
 	fcu     rpcRequest
+
+	// Ports of JSON-RPC, the engine API and the p2p network.
+	httpPort, authPort, p2pPort int
 }
 
 type rpcRequest struct {
@@ -43,15 +53,14 @@ type rpcRequest struct {
 	Params []any
 }
 
-// newGethClient instantiates a new GethClient.
+// newExecClient instantiates a new execClient.
 //
 // The client's data directory is set to a temporary location and it
 // initializes with the genesis and the provided blocks.
-func newGethClient(ctx context.Context, geth string, chaindir string, verbose bool) (*gethClient, error) {
+func newExecClient(ctx context.Context, d *execDriver, cfg *clientConfig, chaindir string, verbose bool) (*execClient, error) {
 	// Load ForkchoiceUpdated from test chain.
-	var fcuRequest rpcRequest
-	fcuFile := filepath.Join(chaindir, "headfcu.json")
-	if err := common.LoadJSON(fcuFile, &fcuRequest); err != nil {
+	fcuRequest, err := loadForkchoice(chaindir)
+	if err != nil {
 		return nil, err
 	}
 
@@ -61,65 +70,64 @@ func newGethClient(ctx context.Context, geth string, chaindir string, verbose bo
 		return nil, err
 	}
 
-	var (
-		args     = ctx.Value(ARGS).(*Args)
-		datadir  = fmt.Sprintf("--datadir=%s", tmp)
-		gcmode   = "--gcmode=archive"
-		loglevel = fmt.Sprintf("--verbosity=%d", args.logLevelInt)
-		// Archive mode requires hash-based scheme.
-		scheme = fmt.Sprintf("--state.scheme=%s", "hash")
-	)
-
-	// Run geth init.
-	options := []string{datadir, gcmode, scheme, loglevel, "init", filepath.Join(chaindir, "genesis.json")}
-	err = runCmd(ctx, geth, verbose, options...)
-	if err != nil {
+	jwt := make([]byte, 32)
+	rand.Read(jwt)
+	c := &execClient{driver: d, cfg: cfg, workdir: tmp, chain: chaindir, jwt: jwt, fcu: fcuRequest}
+	if err := c.allocatePorts(); err != nil {
+		os.RemoveAll(tmp)
 		return nil, err
 	}
-
-	// Run geth import.
-	options = []string{datadir, gcmode, loglevel, "import", filepath.Join(chaindir, "chain.rlp")}
-	err = runCmd(ctx, geth, verbose, options...)
-	if err != nil {
+	if err := os.WriteFile(c.jwtFile(), []byte(hexutil.Encode(jwt)), 0600); err != nil {
+		os.RemoveAll(tmp)
 		return nil, err
 	}
 
-	jwt := make([]byte, 32)
-	rand.Read(jwt)
-	if err := os.WriteFile(fmt.Sprintf("%s/jwt.hex", tmp), []byte(hexutil.Encode(jwt)), 0600); err != nil {
-		return nil, err
+	// Run the initialization commands, e.g. init and import.
+	if d.setup != nil {
+		for _, options := range d.setup(c) {
+			if err := runCmd(ctx, cfg.Bin, verbose, options...); err != nil {
+				os.RemoveAll(tmp)
+				return nil, err
+			}
+		}
 	}
+	return c, nil
+}
 
-	g := &gethClient{path: geth, workdir: tmp, jwt: jwt, fcu: fcuRequest}
-	return g, nil
-}
-
-// Start starts geth, but does not wait for the command to exit.
-func (g *gethClient) Start(ctx context.Context, verbose bool) error {
-	fmt.Println("starting client")
-
-	var (
-		args    = ctx.Value(ARGS).(*Args)
-		options = []string{
-			fmt.Sprintf("--datadir=%s", g.workdir),
-			fmt.Sprintf("--verbosity=%d", args.logLevelInt),
-			fmt.Sprintf("--port=%s", NETWORKPORT),
-			"--gcmode=archive",
-			"--nodiscover",
-			"--http",
-			"--http.api=admin,eth,debug,net,txpool,testing",
-			fmt.Sprintf("--http.addr=%s", HOST),
-			fmt.Sprintf("--http.port=%s", PORT),
-			fmt.Sprintf("--authrpc.port=%s", AUTHPORT),
-			fmt.Sprintf("--authrpc.jwtsecret=%s", fmt.Sprintf("%s/jwt.hex", g.workdir)),
-		}
-	)
-	g.cmd = exec.CommandContext(ctx, g.path, options...)
+// datadir returns the data directory of the client.
+func (c *execClient) datadir() string {
+	return filepath.Join(c.workdir, "data")
+}
+
+// jwtFile returns the file holding the JWT secret of the engine API.
+func (c *execClient) jwtFile() string {
+	return filepath.Join(c.workdir, "jwt.hex")
+}
+
+// genesis returns the genesis file the client is initialized with.
+func (c *execClient) genesis() string {
+	if c.cfg.Genesis != "" {
+		return c.cfg.Genesis
+	}
+	return filepath.Join(c.chain, "genesis.json")
+}
+
+// chainFile returns the file holding the blocks imported into the client.
+func (c *execClient) chainFile() string {
+	return filepath.Join(c.chain, "chain.rlp")
+}
+
+// Start starts the client, but does not wait for the command to exit.
+func (c *execClient) Start(ctx context.Context, verbose bool) error {
+	fmt.Fprintln(os.Stderr, "starting client")
+
+	options := append(c.driver.start(c), c.cfg.Args...)
+	c.cmd = exec.CommandContext(ctx, c.cfg.Bin, options...)
 	if verbose {
-		g.cmd.Stdout = os.Stdout
-		g.cmd.Stderr = os.Stderr
+		c.cmd.Stdout = os.Stdout
+		c.cmd.Stderr = os.Stderr
 	}
-	if err := g.cmd.Start(); err != nil {
+	if err := c.cmd.Start(); err != nil {
 		return err
 	}
 	return nil
@@ -127,31 +135,97 @@ func (g *gethClient) Start(ctx context.Context, verbose bool) error {
 
 // AfterStart is called after the client has been fully started.
 // We send a forkchoiceUpdatedV2 request to the engine to trigger a post-merge forkchoice.
-func (g *gethClient) AfterStart(ctx context.Context) error {
-	auth := node.NewJWTAuth(common.BytesToHash(g.jwt))
-	endpoint := fmt.Sprintf("http://%s:%s", HOST, AUTHPORT)
-	cl, err := rpc.DialOptions(ctx, endpoint, rpc.WithHTTPAuth(auth))
-	if err != nil {
-		return err
-	}
-	defer cl.Close()
-	err = cl.CallContext(ctx, nil, g.fcu.Method, g.fcu.Params...)
-	if err != nil {
-		return fmt.Errorf("geth rejected forkchoiceUpdated: %v", err)
+func (c *execClient) AfterStart(ctx context.Context) error {
+	endpoint := fmt.Sprintf("http://%s:%d", HOST, c.authPort)
+	if err := sendForkchoice(ctx, endpoint, c.jwt, c.fcu); err != nil {
+		return fmt.Errorf("%s %w", c.cfg.Type, err)
 	}
 	return nil
 }
 
 // HttpAddr returns the address where the client is servering its JSON-RPC.
-func (g *gethClient) HttpAddr() string {
-	return fmt.Sprintf("http://%s:%s", HOST, PORT)
+func (c *execClient) HttpAddr() string {
+	return fmt.Sprintf("http://%s:%d", HOST, c.httpPort)
 }
 
 // Close closes the client.
-func (g *gethClient) Close() error {
-	g.cmd.Process.Kill()
-	g.cmd.Wait()
-	return os.RemoveAll(g.workdir)
+func (c *execClient) Close() error {
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	}
+	return os.RemoveAll(c.workdir)
+}
+
+// allocatePorts allocates the ports of the client. The ports are held until all
+// of them are allocated, so that they are distinct. The p2p port must be free
+// for UDP too, which is used for discovery.
+//
+// Another process may still take a port before the client binds it. spawnClient
+// starts the client again if it fails to start.
+func (c *execClient) allocatePorts() error {
+	var held []io.Closer
+	defer func() {
+		for _, l := range held {
+			l.Close()
+		}
+	}()
+	listen := func(host string, udp bool) (int, error) {
+		for range maxPortAttempts {
+			l, err := net.Listen("tcp", host+":0")
+			if err != nil {
+				return 0, err
+			}
+			held = append(held, l)
+			port := l.Addr().(*net.TCPAddr).Port
+			if !udp {
+				return port, nil
+			}
+			if pc, err := net.ListenPacket("udp", fmt.Sprintf("%s:%d", host, port)); err == nil {
+				held = append(held, pc)
+				return port, nil
+			}
+		}
+		return 0, errors.New("can't find a port which is free for TCP and UDP")
+	}
+	var err error
+	if c.httpPort, err = listen(HOST, false); err != nil {
+		return err
+	}
+	if c.authPort, err = listen(HOST, false); err != nil {
+		return err
+	}
+	// The p2p network listens on all interfaces.
+	c.p2pPort, err = listen("", true)
+	return err
+}
+
+// maxPortAttempts is the number of ports tried for a port which must be free
+// for TCP and UDP.
+const maxPortAttempts = 10
+
+// loadForkchoice loads the forkchoiceUpdated request which sets the head of the
+// test chain.
+func loadForkchoice(chaindir string) (rpcRequest, error) {
+	var fcu rpcRequest
+	err := common.LoadJSON(filepath.Join(chaindir, "headfcu.json"), &fcu)
+	return fcu, err
+}
+
+// sendForkchoice sends the forkchoiceUpdated request fcu to the engine API at
+// endpoint.
+func sendForkchoice(ctx context.Context, endpoint string, jwt []byte, fcu rpcRequest) error {
+	auth := node.NewJWTAuth(common.BytesToHash(jwt))
+	cl, err := rpc.DialOptions(ctx, endpoint, rpc.WithHTTPAuth(auth))
+	if err != nil {
+		return err
+	}
+	defer cl.Close()
+	err = cl.CallContext(ctx, nil, fcu.Method, fcu.Params...)
+	if err != nil {
+		return fmt.Errorf("rejected forkchoiceUpdated: %v", err)
+	}
+	return nil
 }
 
 // runCmd runs a command and outputs the command's stdout and stderr to the
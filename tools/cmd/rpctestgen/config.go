@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/naoina/toml"
+)
+
+// clientConfig configures the client tests are filled against. It is read from
+// the client descriptor given by --client-config, a TOML or JSON file:
+//
+//	type = "geth"
+//	bin = "./build/bin/geth"
+//	args = ["--cache=4096"]
+//
+// The command line flags override the settings of the descriptor.
+type clientConfig struct {
+	Type      string   `toml:"type" json:"type"`           // driver, one of drivers
+	Bin       string   `toml:"bin" json:"bin"`             // client binary, default is the driver's
+	Args      []string `toml:"args" json:"args"`           // additional options for starting the client
+	Genesis   string   `toml:"genesis" json:"genesis"`     // genesis file, instead of genesis.json of the chain
+	HTTP      string   `toml:"http" json:"http"`           // JSON-RPC endpoint of an external client
+	AuthRPC   string   `toml:"authrpc" json:"authrpc"`     // engine API endpoint of an external client
+	JWTSecret string   `toml:"jwtsecret" json:"jwtsecret"` // JWT secret file for the engine API of an external client
+
+	logLevel int // --loglevel, from 1 for errors to 5 for tracing
+}
+
+// clientConfig returns the configuration of the client given by the flags.
+func (args *Args) clientConfig() (*clientConfig, error) {
+	cfg := new(clientConfig)
+	if args.ClientConfig != "" {
+		var err error
+		if cfg, err = loadClientConfig(args.ClientConfig); err != nil {
+			return nil, err
+		}
+	}
+	for _, flag := range []struct{ v, cfg *string }{
+		{&args.ClientType, &cfg.Type},
+		{&args.ClientBin, &cfg.Bin},
+		{&args.HTTP, &cfg.HTTP},
+		{&args.AuthRPC, &cfg.AuthRPC},
+		{&args.JWTSecret, &cfg.JWTSecret},
+	} {
+		if *flag.v != "" {
+			*flag.cfg = *flag.v
+		}
+	}
+	if cfg.Type == "" {
+		cfg.Type = "geth"
+	}
+	d, ok := drivers[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported client: %s, must be one of %v", cfg.Type, driverNames())
+	}
+	// The config is shared by the clients of all workers, so it's completed
+	// here rather than when the clients are created.
+	if d, ok := d.(*execDriver); ok && cfg.Bin == "" {
+		cfg.Bin = d.bin
+	}
+	cfg.logLevel = args.logLevelInt
+	return cfg, nil
+}
+
+// loadClientConfig reads a client descriptor. Files ending in .json are JSON,
+// others are TOML.
+func loadClientConfig(file string) (*clientConfig, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read client descriptor: %w", err)
+	}
+	cfg := new(clientConfig)
+	if filepath.Ext(file) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	} else {
+		err = toml.Unmarshal(content, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid client descriptor %s: %w", file, err)
+	}
+	return cfg, nil
+}
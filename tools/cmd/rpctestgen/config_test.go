@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadClientConfig(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		want    clientConfig
+		err     string
+	}{
+		{
+			file: "reth.toml",
+			content: `type = "reth"
+bin = "/usr/local/bin/reth"
+args = ["--rpc.gascap=0"]
+genesis = "genesis.json"
+`,
+			want: clientConfig{Type: "reth", Bin: "/usr/local/bin/reth", Args: []string{"--rpc.gascap=0"}, Genesis: "genesis.json"},
+		},
+		{
+			file:    "external.json",
+			content: `{"type": "external", "http": "http://127.0.0.1:8545", "authrpc": "http://127.0.0.1:8551", "jwtsecret": "jwt.hex"}`,
+			want:    clientConfig{Type: "external", HTTP: "http://127.0.0.1:8545", AuthRPC: "http://127.0.0.1:8551", JWTSecret: "jwt.hex"},
+		},
+		{
+			// Files not ending in .json are TOML.
+			file:    "client.conf",
+			content: `type = "besu"`,
+			want:    clientConfig{Type: "besu"},
+		},
+		{
+			file:    "unknown.json",
+			content: `{"type": "geth", "binary": "geth"}`,
+			err:     "invalid client descriptor",
+		},
+		{
+			file:    "unknown.toml",
+			content: `binary = "geth"`,
+			err:     "invalid client descriptor",
+		},
+		{
+			file:    "syntax.toml",
+			content: `type = geth`,
+			err:     "invalid client descriptor",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadClientConfig(file)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*cfg, tt.want) {
+				t.Errorf("got %+v, want %+v", *cfg, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		if _, err := loadClientConfig(filepath.Join(t.TempDir(), "client.toml")); err == nil {
+			t.Error("no error for missing descriptor")
+		}
+	})
+}
+
+func TestClientConfigFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "client.toml")
+	descriptor := `type = "reth"
+bin = "/usr/local/bin/reth"
+args = ["--rpc.gascap=0"]
+`
+	if err := os.WriteFile(file, []byte(descriptor), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args Args
+		want clientConfig
+		err  string
+	}{
+		{
+			name: "default",
+			want: clientConfig{Type: "geth", Bin: "geth"},
+		},
+		{
+			name: "descriptor",
+			args: Args{ClientConfig: file},
+			want: clientConfig{Type: "reth", Bin: "/usr/local/bin/reth", Args: []string{"--rpc.gascap=0"}},
+		},
+		{
+			name: "flags override descriptor",
+			args: Args{ClientConfig: file, ClientBin: "./reth"},
+			want: clientConfig{Type: "reth", Bin: "./reth", Args: []string{"--rpc.gascap=0"}},
+		},
+		{
+			name: "client flag overrides descriptor",
+			args: Args{ClientConfig: file, ClientType: "erigon"},
+			want: clientConfig{Type: "erigon", Bin: "/usr/local/bin/reth", Args: []string{"--rpc.gascap=0"}},
+		},
+		{
+			name: "external flags",
+			args: Args{ClientType: "external", HTTP: "http://127.0.0.1:8545", AuthRPC: "http://127.0.0.1:8551", JWTSecret: "jwt.hex"},
+			want: clientConfig{Type: "external", HTTP: "http://127.0.0.1:8545", AuthRPC: "http://127.0.0.1:8551", JWTSecret: "jwt.hex"},
+		},
+		{
+			name: "log level",
+			args: Args{logLevelInt: 4},
+			want: clientConfig{Type: "geth", Bin: "geth", logLevel: 4},
+		},
+		{
+			name: "unknown client",
+			args: Args{ClientType: "parity"},
+			err:  "unsupported client: parity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.args.clientConfig()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*cfg, tt.want) {
+				t.Errorf("got %+v, want %+v", *cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientConfigBin(t *testing.T) {
+	tests := []struct {
+		client, bin string
+		want        string
+	}{
+		{client: "geth", want: "geth"},
+		{client: "besu", want: "besu"},
+		{client: "reth", bin: "./target/release/reth", want: "./target/release/reth"},
+		{client: "external", want: ""},
+	}
+	for _, tt := range tests {
+		args := &Args{ClientType: tt.client, ClientBin: tt.bin}
+		cfg, err := args.clientConfig()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.client, err)
+		}
+		if cfg.Bin != tt.want {
+			t.Errorf("%s: got bin %q, want %q", tt.client, cfg.Bin, tt.want)
+		}
+	}
+}
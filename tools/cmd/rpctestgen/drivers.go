@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// driver creates clients of one client implementation.
+type driver interface {
+	// newClient creates a client, which is ready to be started.
+	newClient(ctx context.Context, cfg *clientConfig) (Client, error)
+
+	// supports reports whether the clients serve the method. Tests of other
+	// methods are skipped.
+	supports(method string) bool
+}
+
+// drivers are the supported clients, by the name given to --client.
+var drivers = map[string]driver{
+	"geth":       gethDriver,
+	"nethermind": nethermindDriver,
+	"besu":       besuDriver,
+	"erigon":     erigonDriver,
+	"reth":       rethDriver,
+	"external":   externalDriver{},
+}
+
+// driverNames returns the names of the supported clients.
+func driverNames() []string {
+	return slices.Sorted(maps.Keys(drivers))
+}
+
+// execDriver runs a client binary. The client is initialized with the test
+// chain in a temporary data directory, and listens on the ports allocated for it.
+type execDriver struct {
+	bin string // default client binary
+
+	// setup returns the commands which initialize the data directory with the
+	// genesis and import the chain. They are run in order.
+	setup func(c *execClient) [][]string
+
+	// start returns the options the client is started with.
+	start func(c *execClient) []string
+
+	// unsupported are the namespaces of the spec which the client doesn't
+	// implement, e.g. testing, which only geth has.
+	unsupported []string
+}
+
+func (d *execDriver) newClient(ctx context.Context, cfg *clientConfig) (Client, error) {
+	if cfg.HTTP != "" || cfg.AuthRPC != "" || cfg.JWTSecret != "" {
+		return nil, fmt.Errorf("%s client: http, authrpc and jwtsecret are only used by the external client", cfg.Type)
+	}
+	args := ctx.Value(ARGS).(*Args)
+	return newExecClient(ctx, d, cfg, args.ChainDir, args.Verbose)
+}
+
+func (d *execDriver) supports(method string) bool {
+	namespace, _, _ := strings.Cut(method, "_")
+	return !slices.Contains(d.unsupported, namespace)
+}
+
+var gethDriver = &execDriver{
+	bin: "geth",
+	setup: func(c *execClient) [][]string {
+		var (
+			datadir  = fmt.Sprintf("--datadir=%s", c.datadir())
+			gcmode   = "--gcmode=archive"
+			loglevel = fmt.Sprintf("--verbosity=%d", c.cfg.logLevel)
+			// Archive mode requires hash-based scheme.
+			scheme = fmt.Sprintf("--state.scheme=%s", "hash")
+		)
+		return [][]string{
+			{datadir, gcmode, scheme, loglevel, "init", c.genesis()},
+			{datadir, gcmode, loglevel, "import", c.chainFile()},
+		}
+	},
+	start: func(c *execClient) []string {
+		return []string{
+			fmt.Sprintf("--datadir=%s", c.datadir()),
+			fmt.Sprintf("--verbosity=%d", c.cfg.logLevel),
+			fmt.Sprintf("--port=%d", c.p2pPort),
+			"--gcmode=archive",
+			"--nodiscover",
+			"--http",
+			"--http.api=admin,eth,debug,net,txpool,testing",
+			fmt.Sprintf("--http.addr=%s", HOST),
+			fmt.Sprintf("--http.port=%d", c.httpPort),
+			fmt.Sprintf("--authrpc.port=%d", c.authPort),
+			fmt.Sprintf("--authrpc.jwtsecret=%s", c.jwtFile()),
+		}
+	},
+}
+
+// Nethermind imports the chain when it starts. It reads the genesis from a
+// chain specification in its own format, which can be set as the genesis in
+// the client descriptor.
+var nethermindDriver = &execDriver{
+	bin: "nethermind",
+	start: func(c *execClient) []string {
+		return []string{
+			"--config=none",
+			fmt.Sprintf("--datadir=%s", c.datadir()),
+			fmt.Sprintf("--log=%s", logLevelName(c)),
+			fmt.Sprintf("--Init.ChainSpecPath=%s", c.genesis()),
+			"--Init.DiscoveryEnabled=false",
+			"--Hive.Enabled=true",
+			fmt.Sprintf("--Hive.ChainFile=%s", c.chainFile()),
+			"--Pruning.Mode=None",
+			"--Sync.FastSync=false",
+			"--Sync.SnapSync=false",
+			fmt.Sprintf("--Network.P2PPort=%d", c.p2pPort),
+			fmt.Sprintf("--Network.DiscoveryPort=%d", c.p2pPort),
+			"--JsonRpc.Enabled=true",
+			"--JsonRpc.EnabledModules=[Admin,Eth,Debug,Net,TxPool]",
+			fmt.Sprintf("--JsonRpc.Host=%s", HOST),
+			fmt.Sprintf("--JsonRpc.Port=%d", c.httpPort),
+			fmt.Sprintf("--JsonRpc.EngineHost=%s", HOST),
+			fmt.Sprintf("--JsonRpc.EnginePort=%d", c.authPort),
+			fmt.Sprintf("--JsonRpc.JwtSecretFile=%s", c.jwtFile()),
+		}
+	},
+	unsupported: []string{"testing"},
+}
+
+var besuDriver = &execDriver{
+	bin: "besu",
+	setup: func(c *execClient) [][]string {
+		return [][]string{
+			append(besuOptions(c), "blocks", "import", fmt.Sprintf("--from=%s", c.chainFile())),
+		}
+	},
+	start: func(c *execClient) []string {
+		return append(besuOptions(c),
+			"--sync-mode=FULL",
+			fmt.Sprintf("--p2p-port=%d", c.p2pPort),
+			"--discovery-enabled=false",
+			"--rpc-http-enabled",
+			"--rpc-http-api=ADMIN,ETH,DEBUG,NET,TXPOOL",
+			fmt.Sprintf("--rpc-http-host=%s", HOST),
+			fmt.Sprintf("--rpc-http-port=%d", c.httpPort),
+			fmt.Sprintf("--engine-rpc-port=%d", c.authPort),
+			fmt.Sprintf("--engine-jwt-secret=%s", c.jwtFile()),
+		)
+	},
+	unsupported: []string{"testing"},
+}
+
+// besuOptions returns the options shared by all besu commands.
+func besuOptions(c *execClient) []string {
+	return []string{
+		fmt.Sprintf("--data-path=%s", c.datadir()),
+		fmt.Sprintf("--genesis-file=%s", c.genesis()),
+		// Forest storage keeps the state of all blocks.
+		"--data-storage-format=FOREST",
+		fmt.Sprintf("--logging=%s", logLevelName(c)),
+	}
+}
+
+var erigonDriver = &execDriver{
+	bin: "erigon",
+	setup: func(c *execClient) [][]string {
+		datadir := fmt.Sprintf("--datadir=%s", c.datadir())
+		return [][]string{
+			{"init", datadir, c.genesis()},
+			{"import", datadir, c.chainFile()},
+		}
+	},
+	start: func(c *execClient) []string {
+		return []string{
+			fmt.Sprintf("--datadir=%s", c.datadir()),
+			fmt.Sprintf("--verbosity=%d", c.cfg.logLevel),
+			fmt.Sprintf("--port=%d", c.p2pPort),
+			"--prune.mode=archive",
+			"--nodiscover",
+			"--no-downloader",
+			"--externalcl",
+			"--http",
+			"--http.api=admin,eth,debug,net,txpool",
+			fmt.Sprintf("--http.addr=%s", HOST),
+			fmt.Sprintf("--http.port=%d", c.httpPort),
+			fmt.Sprintf("--authrpc.port=%d", c.authPort),
+			fmt.Sprintf("--authrpc.jwtsecret=%s", c.jwtFile()),
+		}
+	},
+	unsupported: []string{"testing"},
+}
+
+var rethDriver = &execDriver{
+	bin: "reth",
+	setup: func(c *execClient) [][]string {
+		var (
+			datadir   = fmt.Sprintf("--datadir=%s", c.datadir())
+			chain     = fmt.Sprintf("--chain=%s", c.genesis())
+			verbosity = rethVerbosity(c)
+		)
+		return [][]string{
+			{"init", datadir, chain, verbosity},
+			{"import", datadir, chain, verbosity, c.chainFile()},
+		}
+	},
+	start: func(c *execClient) []string {
+		return []string{
+			"node",
+			fmt.Sprintf("--datadir=%s", c.datadir()),
+			fmt.Sprintf("--chain=%s", c.genesis()),
+			rethVerbosity(c),
+			fmt.Sprintf("--port=%d", c.p2pPort),
+			"--disable-discovery",
+			"--http",
+			"--http.api=admin,eth,debug,net,txpool",
+			fmt.Sprintf("--http.addr=%s", HOST),
+			fmt.Sprintf("--http.port=%d", c.httpPort),
+			fmt.Sprintf("--authrpc.port=%d", c.authPort),
+			fmt.Sprintf("--authrpc.jwtsecret=%s", c.jwtFile()),
+		}
+	},
+	unsupported: []string{"testing"},
+}
+
+// rethVerbosity returns the reth option setting the log level, -v for errors
+// up to -vvvvv for tracing.
+func rethVerbosity(c *execClient) string {
+	return "-" + strings.Repeat("v", c.cfg.logLevel)
+}
+
+// logLevelName returns the name of the --loglevel of rpctestgen, e.g. INFO.
+func logLevelName(c *execClient) string {
+	return []string{"ERROR", "WARN", "INFO", "DEBUG", "TRACE"}[c.cfg.logLevel-1]
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestDriverSupports(t *testing.T) {
+	tests := []struct {
+		driver string
+		method string
+		want   bool
+	}{
+		{"geth", "testing_buildBlockV1", true},
+		{"geth", "eth_call", true},
+		{"nethermind", "testing_buildBlockV1", false},
+		{"besu", "testing_buildBlockV1", false},
+		{"erigon", "testing_buildBlockV1", false},
+		{"reth", "testing_buildBlockV1", false},
+		{"reth", "eth_call", true},
+		{"reth", "debug_getRawBlock", true},
+		{"external", "testing_buildBlockV1", true},
+	}
+	for _, tt := range tests {
+		if got := drivers[tt.driver].supports(tt.method); got != tt.want {
+			t.Errorf("%s supports %s: got %v, want %v", tt.driver, tt.method, got, tt.want)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/rpc"
@@ -15,6 +17,7 @@ import (
 type ethclientHandler struct {
 	rpc       *rpc.Client
 	logFile   *os.File
+	logName   string
 	transport *loggingRoundTrip
 }
 
@@ -35,21 +38,50 @@ func newEthclientHandler(addr string) (*ethclientHandler, error) {
 	}, nil
 }
 
+// RotateLog discards the current log and starts a new log, which is written to
+// filename by CommitLog. Until then, the log is kept in a temporary file next
+// to filename, so that filename is never left half-written.
 func (l *ethclientHandler) RotateLog(filename string) error {
-	if l.logFile != nil {
-		if err := l.logFile.Close(); err != nil {
-			return err
-		}
-	}
-	f, err := os.Create(filename)
+	l.discardLog()
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
 	if err != nil {
 		return err
 	}
 	l.logFile = f
+	l.logName = filename
 	l.transport.w = f
 	return nil
 }
 
+// CommitLog closes the current log and moves it to the file given to RotateLog.
+func (l *ethclientHandler) CommitLog() error {
+	if l.logFile == nil {
+		return errors.New("no log to commit")
+	}
+	f := l.logFile
+	l.logFile = nil
+	// Temporary files are only readable by the owner.
+	f.Chmod(0644)
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), l.logName); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// discardLog closes and removes the current log, unless it was committed.
+func (l *ethclientHandler) discardLog() {
+	if l.logFile != nil {
+		l.logFile.Close()
+		os.Remove(l.logFile.Name())
+		l.logFile = nil
+	}
+}
+
 // WriteComment adds the given text as a comment to the current log file.
 func (l *ethclientHandler) WriteComment(text string) error {
 	var b strings.Builder
@@ -66,10 +98,9 @@ func (l *ethclientHandler) WriteComment(text string) error {
 	return err
 }
 
+// Close discards the current log, unless it was committed.
 func (l *ethclientHandler) Close() {
-	if l.logFile != nil {
-		l.logFile.Close()
-	}
+	l.discardLog()
 }
 
 // loggingRoundTrip writes requests and responses to the test log.
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		commit  bool
+		old     string // content of the fixture before the test, if any
+		want    string // content of the fixture after the test, if any
+		wantErr bool
+	}{
+		{name: "commit", commit: true, want: "// new\n"},
+		{name: "commit over old", commit: true, old: "old", want: "// new\n"},
+		{name: "discard", commit: false},
+		{name: "discard keeps old", commit: false, old: "old", want: "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "test.io")
+			if tt.old != "" {
+				if err := os.WriteFile(filename, []byte(tt.old), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			h := &ethclientHandler{transport: new(loggingRoundTrip)}
+			if err := h.RotateLog(filename); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.WriteComment("new"); err != nil {
+				t.Fatal(err)
+			}
+			// The fixture is only written on commit.
+			if content, _ := os.ReadFile(filename); string(content) != tt.old {
+				t.Errorf("fixture written before commit: %q", content)
+			}
+			if tt.commit {
+				if err := h.CommitLog(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			h.Close()
+
+			content, err := os.ReadFile(filename)
+			switch {
+			case tt.want == "" && !os.IsNotExist(err):
+				t.Errorf("fixture exists: %q", content)
+			case tt.want != "" && string(content) != tt.want:
+				t.Errorf("got fixture %q (%v), want %q", content, err, tt.want)
+			}
+			if tt.commit {
+				if info, err := os.Stat(filename); err == nil && info.Mode().Perm() != 0644 {
+					t.Errorf("got mode %v, want 0644", info.Mode().Perm())
+				}
+			}
+			// No temporary file is left behind.
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				if e.Name() != "test.io" {
+					t.Errorf("leftover file %s", e.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestRotateLogDiscardsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	h := &ethclientHandler{transport: new(loggingRoundTrip)}
+	defer h.Close()
+	first, second := filepath.Join(dir, "first.io"), filepath.Join(dir, "second.io")
+	if err := h.RotateLog(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.RotateLog(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.CommitLog(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.CommitLog(); err == nil {
+		t.Error("no error committing twice")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "second.io" {
+		t.Errorf("wrong files after rotation: %v", entries)
+	}
+}
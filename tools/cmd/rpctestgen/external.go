@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// externalDriver connects to a client which is already running, e.g. one
+// deployed in production. The client must serve the test chain.
+type externalDriver struct{}
+
+func (externalDriver) newClient(ctx context.Context, cfg *clientConfig) (Client, error) {
+	if cfg.HTTP == "" {
+		return nil, errors.New("external client: http endpoint is required")
+	}
+	if cfg.Bin != "" || len(cfg.Args) > 0 || cfg.Genesis != "" {
+		return nil, errors.New("external client: bin, args and genesis are only used by clients run by rpctestgen")
+	}
+	c := &externalClient{http: cfg.HTTP, authrpc: cfg.AuthRPC}
+	if cfg.AuthRPC == "" {
+		if cfg.JWTSecret != "" {
+			return nil, errors.New("external client: jwtsecret requires the authrpc endpoint")
+		}
+		return c, nil
+	}
+
+	// With the engine API, the head of the test chain is set after connecting.
+	if cfg.JWTSecret == "" {
+		return nil, errors.New("external client: authrpc endpoint requires jwtsecret")
+	}
+	var err error
+	if c.jwt, err = readJWTSecret(cfg.JWTSecret); err != nil {
+		return nil, err
+	}
+	args := ctx.Value(ARGS).(*Args)
+	if c.fcu, err = loadForkchoice(args.ChainDir); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// supports reports true for all methods. The operator of the client decides
+// which namespaces it serves.
+func (externalDriver) supports(method string) bool {
+	return true
+}
+
+// externalClient is a client which isn't run by rpctestgen.
+type externalClient struct {
+	http    string
+	authrpc string
+	jwt     []byte
+	fcu     rpcRequest
+}
+
+// Start does nothing, the client is already running.
+func (c *externalClient) Start(ctx context.Context, verbose bool) error {
+	fmt.Fprintln(os.Stderr, "using client at", c.http)
+	return nil
+}
+
+// AfterStart sets the head of the test chain through the engine API, if the
+// client's engine API endpoint is known.
+func (c *externalClient) AfterStart(ctx context.Context) error {
+	if c.authrpc == "" {
+		return nil
+	}
+	if err := sendForkchoice(ctx, c.authrpc, c.jwt, c.fcu); err != nil {
+		return fmt.Errorf("external client %w", err)
+	}
+	return nil
+}
+
+// HttpAddr returns the address where the client is servering its JSON-RPC.
+func (c *externalClient) HttpAddr() string {
+	return c.http
+}
+
+// Close does nothing, the client keeps running.
+func (c *externalClient) Close() error {
+	return nil
+}
+
+// readJWTSecret reads a hex-encoded JWT secret, as written by clients.
+func readJWTSecret(file string) ([]byte, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read JWT secret: %w", err)
+	}
+	jwt := common.FromHex(strings.TrimSpace(string(content)))
+	if len(jwt) != 32 {
+		return nil, fmt.Errorf("invalid JWT secret in %s: want 32 hex-encoded bytes", file)
+	}
+	return jwt, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-apis/tools/testgen"
+)
+
+// staleFixtures returns the test fixtures in dir which don't belong to a test of
+// testgen.AllMethods, e.g. because the test was removed or renamed.
+func staleFixtures(dir string) ([]string, error) {
+	known := make(map[string]bool)
+	for _, methodTest := range testgen.AllMethods {
+		for _, test := range methodTest.Tests {
+			known[filepath.Join(methodTest.Name, test.Name+".io")] = true
+		}
+	}
+	var stale []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".io" {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if !known[rel] {
+			stale = append(stale, path)
+		}
+		return nil
+	})
+	return stale, err
+}
+
+// pruneFixtures removes the stale test fixtures in dir, and the method
+// directories left empty.
+func pruneFixtures(dir string) error {
+	stale, err := staleFixtures(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range stale {
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+		fmt.Println("removed", file)
+
+		// Remove the directory of the method if it has no tests left.
+		methodDir := filepath.Dir(file)
+		if entries, err := os.ReadDir(methodDir); err == nil && len(entries) == 0 && methodDir != filepath.Clean(dir) {
+			if err := os.Remove(methodDir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkFixtures compares the test fixtures filled into filledDir with the
+// fixtures in dir. It fails if a fixture differs or is missing, or if dir has
+// stale fixtures.
+func checkFixtures(dir, filledDir string, jobs []*fillJob) error {
+	var problems []string
+	for _, job := range jobs {
+		rel, err := filepath.Rel(filledDir, job.filename)
+		if err != nil {
+			return err
+		}
+		filled, err := os.ReadFile(job.filename)
+		if err != nil {
+			return err
+		}
+		committed, err := os.ReadFile(filepath.Join(dir, rel))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			problems = append(problems, fmt.Sprintf("missing: %s", filepath.Join(dir, rel)))
+		case err != nil:
+			return err
+		case !bytes.Equal(filled, committed):
+			problems = append(problems, fmt.Sprintf("differs: %s", filepath.Join(dir, rel)))
+		}
+	}
+	stale, err := staleFixtures(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range stale {
+		problems = append(problems, fmt.Sprintf("stale: %s", file))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("test fixtures are out of date:\n%s", strings.Join(problems, "\n"))
+	}
+	fmt.Println("test fixtures are up to date")
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/execution-apis/tools/testgen"
+)
+
+// knownFixture returns the fixture path of a test in testgen, relative to the
+// tests directory.
+func knownFixture() string {
+	m := testgen.AllMethods[0]
+	return filepath.Join(m.Name, m.Tests[0].Name+".io")
+}
+
+// writeFixtures writes the given fixtures, relative to dir, with their names as
+// content.
+func writeFixtures(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStaleFixtures(t *testing.T) {
+	known := knownFixture()
+	tests := []struct {
+		name  string
+		files []string
+		stale []string
+	}{
+		{"none", []string{known, "genesis.json", "chain.rlp"}, nil},
+		{"removed test", []string{known, filepath.Join(filepath.Dir(known), "removed-test.io")}, []string{filepath.Join(filepath.Dir(known), "removed-test.io")}},
+		{"removed method", []string{known, "eth_removed/test.io"}, []string{filepath.Join("eth_removed", "test.io")}},
+		{"other files", []string{filepath.Join(filepath.Dir(known), "notes.txt")}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFixtures(t, dir, tt.files...)
+			stale, err := staleFixtures(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, f := range tt.stale {
+				want = append(want, filepath.Join(dir, f))
+			}
+			if !slices.Equal(stale, want) {
+				t.Errorf("got stale %v, want %v", stale, want)
+			}
+		})
+	}
+}
+
+func TestPruneFixtures(t *testing.T) {
+	dir := t.TempDir()
+	known := knownFixture()
+	removedTest := filepath.Join(filepath.Dir(known), "removed-test.io")
+	writeFixtures(t, dir, known, removedTest, "eth_removed/test.io", "genesis.json")
+
+	if err := pruneFixtures(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{known, "genesis.json"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("%s removed: %v", f, err)
+		}
+	}
+	for _, f := range []string{removedTest, "eth_removed"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); !os.IsNotExist(err) {
+			t.Errorf("%s not removed", f)
+		}
+	}
+}
+
+func TestCheckFixtures(t *testing.T) {
+	known := knownFixture()
+	tests := []struct {
+		name      string
+		committed map[string]string
+		problems  []string
+	}{
+		{
+			name:      "up to date",
+			committed: map[string]string{known: known},
+		},
+		{
+			name:      "differs",
+			committed: map[string]string{known: "old"},
+			problems:  []string{"differs: " + known},
+		},
+		{
+			name:      "missing",
+			committed: map[string]string{},
+			problems:  []string{"missing: " + known},
+		},
+		{
+			name:      "stale",
+			committed: map[string]string{known: known, "eth_removed/test.io": ""},
+			problems:  []string{"stale: " + filepath.Join("eth_removed", "test.io")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, filled := t.TempDir(), t.TempDir()
+			writeFixtures(t, filled, known)
+			for f, content := range tt.committed {
+				writeFixtures(t, dir, f)
+				if err := os.WriteFile(filepath.Join(dir, f), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			jobs := []*fillJob{{filename: filepath.Join(filled, known)}}
+
+			err := checkFixtures(dir, filled, jobs)
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("no error for out of date fixtures")
+			}
+			for _, p := range tt.problems {
+				kind, file, _ := strings.Cut(p, ": ")
+				if !strings.Contains(err.Error(), kind+": "+filepath.Join(dir, file)) {
+					t.Errorf("error doesn't report %q:\n%v", p, err)
+				}
+			}
+		})
+	}
+}
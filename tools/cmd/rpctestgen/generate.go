@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/cespare/cp"
@@ -16,122 +19,320 @@ import (
 
 // runGenerator generates test fixtures against the specified client and writes
 // them to the output directory.
+//
+// Tests which modify the state of the client are filled in order against a
+// client of their own. The other tests are distributed over a pool of clients,
+// one per worker. An external client can't be duplicated, so if tests modify its
+// state, all tests are filled in order against it.
 func runGenerator(ctx context.Context) error {
 	args := ctx.Value(ARGS).(*Args)
+	if args.Check && args.Prune {
+		return errors.New("--prune can't be used with --check")
+	}
+
+	// In check mode, the fixtures are filled in a temporary directory and
+	// compared with the fixtures in the output directory.
+	outDir := args.OutDir
+	if args.Check {
+		tmp, err := os.MkdirTemp("", "rpctestgen-check-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		outDir = tmp
+	}
 
 	// Initialize generated chain.
 	chain, err := testgen.NewChain(args.ChainDir)
 	if err != nil {
 		return err
 	}
-	if err := copyChainFiles(args.ChainDir, args.OutDir); err != nil {
-		return err
-	}
-
-	// Start Ethereum client.
-	client, err := spawnClient(ctx, args)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
-
-	err = client.AfterStart(ctx)
-	if err != nil {
+	if err := copyChainFiles(args.ChainDir, outDir); err != nil {
 		return err
 	}
 
 	// Generate test fixtures for all methods. Store them in the format:
 	// outputDir/methodName/testName.io
-	fmt.Println("filling tests...")
-	tests := testgen.AllMethods
-	fails := 0
-	for _, methodTest := range tests {
+	//
+	// Tests of a method sharing a name write the same file. They are filled
+	// one after the other by the same worker, in the order they are defined,
+	// so the file is always written by the last of them.
+	var (
+		jobs, readOnly, mutating []*fillJob
+		byFile                   = make(map[string]*fillJob)
+		clientDriver             = drivers[args.client.Type]
+	)
+	for _, methodTest := range testgen.AllMethods {
 		// Skip tests that don't match regexp.
 		if !args.tests.MatchString(methodTest.Name) {
 			continue
 		}
+		if !clientDriver.supports(methodTest.Name) {
+			fmt.Printf("skipping %s: not supported by %s\n", methodTest.Name, args.client.Type)
+			continue
+		}
 
-		methodDir := fmt.Sprintf("%s/%s", args.OutDir, methodTest.Name)
+		methodDir := fmt.Sprintf("%s/%s", outDir, methodTest.Name)
 		if err := mkdir(methodDir); err != nil {
 			return err
 		}
 		for _, test := range methodTest.Tests {
-			filename := fmt.Sprintf("%s/%s.io", methodDir, test.Name)
-			fmt.Printf("generating %s", filename)
+			job := &fillJob{
+				method:   methodTest.Name,
+				test:     test,
+				filename: fmt.Sprintf("%s/%s.io", methodDir, test.Name),
+				timeout:  args.testTimeout(test),
+			}
+			if first, ok := byFile[job.filename]; ok {
+				if first.test.MutatesState != test.MutatesState {
+					return fmt.Errorf("tests %s/%s share a name, but only one of them mutates state", methodTest.Name, test.Name)
+				}
+				last := first
+				for last.next != nil {
+					last = last.next
+				}
+				last.next = job
+				continue
+			}
+			byFile[job.filename] = job
+			jobs = append(jobs, job)
+			if test.MutatesState {
+				mutating = append(mutating, job)
+			} else {
+				readOnly = append(readOnly, job)
+			}
+		}
+	}
 
-			// Connect ethclient to Ethereum client. This happens
-			// every test to force the json-rpc id to always be 0.
-			handler, err := newEthclientHandler(client.HttpAddr())
+	fmt.Println("filling tests...")
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		fails int
+		errs  []error
+	)
+	fill := func(chain *testgen.Chain, jobs []*fillJob, workers int) {
+		queue := make(chan *fillJob, len(jobs))
+		for _, job := range jobs {
+			queue <- job
+		}
+		close(queue)
+		for range min(workers, len(jobs)) {
+			wg.Go(func() {
+				n, err := fillTests(ctx, args, chain, queue)
+				mu.Lock()
+				defer mu.Unlock()
+				fails += n
+				if err != nil {
+					errs = append(errs, err)
+				}
+			})
+		}
+	}
+	_, external := clientDriver.(externalDriver)
+	switch {
+	case external && len(mutating) > 0:
+		// All workers share the external client, so the tests which modify its
+		// state can't run alongside the others. All tests are filled in order.
+		if args.Workers > 1 {
+			return errors.New("--workers can't be used with the external client if tests modify the client state")
+		}
+		fill(chain, jobs, 1)
+	default:
+		fill(chain, readOnly, args.Workers)
+		if len(mutating) > 0 {
+			// The mutating tests track the nonces of the senders in the chain, so
+			// they get a copy of their own.
+			mutatingChain, err := testgen.NewChain(args.ChainDir)
 			if err != nil {
 				return err
 			}
+			fill(mutatingChain, mutating, 1)
+		}
+	}
+	wg.Wait()
 
-			// Write the exchange for each test in a separte file.
-			handler.RotateLog(filename)
-			if test.About != "" {
-				handler.WriteComment(test.About)
-			}
-			if test.SpecOnly {
-				if test.About != "" {
-					handler.WriteComment("")
-				}
-				handler.WriteComment("speconly: client response is only checked for schema validity.")
-			}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if fails > 0 {
+		return fmt.Errorf("%d tests failed to fill", fails)
+	}
 
-			// Fail test fill if request exceeds timeout.
-			ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
-			defer cancel()
+	switch {
+	case args.Prune:
+		return pruneFixtures(args.OutDir)
+	case args.Check:
+		return checkFixtures(args.OutDir, outDir, jobs)
+	}
+	return nil
+}
 
-			err = test.Run(ctx, testgen.NewT(handler.rpc, chain))
-			if err != nil {
-				fmt.Println(" fail.")
-				fmt.Fprintf(os.Stderr, "failed to fill %s/%s: %s\n", methodTest.Name, test.Name, err)
+// fillJob is a test to be filled.
+type fillJob struct {
+	method   string
+	test     testgen.Test
+	filename string
+	timeout  time.Duration
+
+	// next is a test with the same name, filled after this one.
+	next *fillJob
+}
+
+// defaultTimeout is the time a test may take, unless the test sets a timeout
+// of its own.
+const defaultTimeout = 3 * time.Second
+
+// testTimeout returns the time the test may take. The --timeout flag overrides
+// the timeout of the test.
+func (args *Args) testTimeout(test testgen.Test) time.Duration {
+	switch {
+	case args.Timeout > 0:
+		return args.Timeout
+	case test.Timeout > 0:
+		return test.Timeout
+	}
+	return defaultTimeout
+}
+
+// Tests failing because of a connection error are retried, as the error may be
+// transient. The delay between attempts doubles, starting from fillRetryDelay.
+const (
+	fillAttempts   = 3
+	fillRetryDelay = 500 * time.Millisecond
+)
+
+// fillTests starts a client and fills the tests received from jobs against it
+// in order, until jobs is closed. It returns the number of tests which failed
+// to fill.
+func fillTests(ctx context.Context, args *Args, chain *testgen.Chain, jobs <-chan *fillJob) (int, error) {
+	// Start Ethereum client.
+	client, err := spawnClient(ctx, args)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	err = client.AfterStart(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	fails := 0
+	for first := range jobs {
+		for job := first; job != nil; job = job.next {
+			if err := fillTest(ctx, client, chain, job); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to fill %s/%s: %s\n", job.method, job.test.Name, err)
 				fails++
 				continue
 			}
-			fmt.Println("  done.")
-			handler.Close()
+			fmt.Printf("generated %s\n", job.filename)
 		}
 	}
+	return fails, nil
+}
 
-	if fails > 0 {
-		return fmt.Errorf("%d tests failed to fill", fails)
+// fillTest runs a test against the client and writes the exchange to the
+// test's file. Tests which don't modify the client state are retried on
+// transient connection errors.
+func fillTest(ctx context.Context, client Client, chain *testgen.Chain, job *fillJob) error {
+	delay := fillRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fillTestOnce(ctx, client, chain, job)
+		if err == nil || attempt == fillAttempts || job.test.MutatesState || !isTransient(err) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "retrying %s/%s in %v: %s\n", job.method, job.test.Name, delay, err)
+		time.Sleep(delay)
+		delay *= 2
 	}
-	return nil
 }
 
+// fillTestOnce runs a test against the client and writes the exchange to the
+// test's file. The file is only written if the test succeeds.
+func fillTestOnce(ctx context.Context, client Client, chain *testgen.Chain, job *fillJob) error {
+	// Connect ethclient to Ethereum client. This happens
+	// every test to force the json-rpc id to always be 0.
+	handler, err := newEthclientHandler(client.HttpAddr())
+	if err != nil {
+		return err
+	}
+	defer handler.Close()
+
+	// Write the exchange for each test in a separte file.
+	if err := handler.RotateLog(job.filename); err != nil {
+		return err
+	}
+	test := job.test
+	if test.About != "" {
+		handler.WriteComment(test.About)
+	}
+	if test.SpecOnly {
+		if test.About != "" {
+			handler.WriteComment("")
+		}
+		handler.WriteComment("speconly: client response is only checked for schema validity.")
+	}
+	if test.ValidParams {
+		handler.WriteComment("validparams: request parameters are valid, the request fails for other reasons.")
+	}
+
+	// Fail test fill if request exceeds timeout.
+	ctx, cancel := context.WithTimeout(ctx, job.timeout)
+	defer cancel()
+
+	if err := test.Run(ctx, testgen.NewT(handler.rpc, chain)); err != nil {
+		return err
+	}
+	return handler.CommitLog()
+}
+
+// isTransient reports whether err is a connection error, which may not occur
+// again when the test is retried.
+func isTransient(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Clients which fail to start are started again, with new ports, as a port
+// allocated for a client may have been taken by another process before the
+// client bound it.
+const startAttempts = 3
+
 // spawnClient starts an Ethereum client on a separate thread.
 //
 // It waits until the client is responding to JSON-RPC requests
 // before returning.
 func spawnClient(ctx context.Context, args *Args) (Client, error) {
-	var (
-		client Client
-		err    error
-	)
-
-	// Initialize specified client and start it in a separate thread.
-	switch args.ClientType {
-	case "geth":
-		client, err = newGethClient(ctx, args.ClientBin, args.ChainDir, args.Verbose)
+	for attempt := 1; ; attempt++ {
+		// Initialize specified client and start it in a separate thread.
+		client, err := drivers[args.client.Type].newClient(ctx, args.client)
 		if err != nil {
 			return nil, err
 		}
-		client.Start(ctx, args.Verbose)
-	default:
-		return nil, fmt.Errorf("unsupported client: %s", args.ClientType)
+		if err = startClient(ctx, client, args.Verbose); err == nil {
+			return client, nil
+		}
+		client.Close()
+		if attempt == startAttempts {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "client failed to start, retrying: %s\n", err)
+	}
+}
+
+// startClient starts the client, and waits until it accepts requests.
+func startClient(ctx context.Context, client Client, verbose bool) error {
+	if err := client.Start(ctx, verbose); err != nil {
+		return err
 	}
 
 	// Try to connect for 5 seconds. Error otherwise.
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-
-	err = tryConnection(ctx, fmt.Sprintf("http://%s:%s", HOST, PORT), 500*time.Millisecond)
-	if err != nil {
-		return nil, err
-	}
-
-	return client, nil
+	return tryConnection(ctx, client.HttpAddr(), 500*time.Millisecond)
 }
 
 // mkdir makes a directory at the specified path, if it doesn't already exist.
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum/execution-apis/tools/testgen"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{syscall.ECONNREFUSED, true},
+		{syscall.ECONNRESET, true},
+		{io.EOF, true},
+		{io.ErrUnexpectedEOF, true},
+		{&url.Error{Op: "Post", URL: "http://127.0.0.1:8545", Err: syscall.ECONNREFUSED}, true},
+		{fmt.Errorf("request failed: %w", io.EOF), true},
+		{context.DeadlineExceeded, false},
+		{errors.New("execution reverted"), false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v): got %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestTestTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		flag time.Duration
+		test time.Duration
+		want time.Duration
+	}{
+		{"default", 0, 0, defaultTimeout},
+		{"test", 0, 10 * time.Second, 10 * time.Second},
+		{"flag", time.Second, 0, time.Second},
+		{"flag overrides test", time.Second, 10 * time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		args := &Args{Timeout: tt.flag}
+		if got := args.testTimeout(testgen.Test{Timeout: tt.test}); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
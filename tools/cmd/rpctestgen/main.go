@@ -5,28 +5,37 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"time"
 
 	"github.com/alexflint/go-arg"
 )
 
-const (
-	HOST        string = "127.0.0.1"
-	PORT        string = "13375"
-	NETWORKPORT string = "13376"
-	AUTHPORT    string = "13377"
-)
+// HOST is the address clients run by rpctestgen listen on. Their ports are
+// allocated dynamically, so that several clients can run at once.
+const HOST string = "127.0.0.1"
 
 type Args struct {
-	ClientType  string `arg:"--client" help:"client type" default:"geth"`
-	ClientBin   string `arg:"--bin" help:"path to client binary" default:"geth"`
-	OutDir      string `arg:"--out" help:"directory where test fixtures will be written" default:"tests"`
-	ChainDir    string `arg:"--chain" help:"path to directory with chain.rlp and genesis.json"`
-	Verbose     bool   `arg:"-v,--verbose" help:"verbosity level of rpctestgen"`
-	LogLevel    string `arg:"--loglevel" help:"log level of client" default:"info"`
-	TestsRegexp string `arg:"--tests" help:"regex of tests to fill" default:".*"`
+	ClientType   string        `arg:"--client" help:"client type: geth, nethermind, besu, erigon, reth or external (default: geth)"`
+	ClientBin    string        `arg:"--bin" help:"path to client binary (default: client type)"`
+	ClientConfig string        `arg:"--client-config" help:"path to client descriptor (TOML or JSON)"`
+	HTTP         string        `arg:"--http" help:"JSON-RPC endpoint of external client"`
+	AuthRPC      string        `arg:"--authrpc" help:"engine API endpoint of external client"`
+	JWTSecret    string        `arg:"--jwtsecret" help:"path to JWT secret of external client's engine API"`
+	OutDir       string        `arg:"--out" help:"directory where test fixtures will be written" default:"tests"`
+	ChainDir     string        `arg:"--chain" help:"path to directory with chain.rlp and genesis.json"`
+	Verbose      bool          `arg:"-v,--verbose" help:"verbosity level of rpctestgen"`
+	LogLevel     string        `arg:"--loglevel" help:"log level of client" default:"info"`
+	TestsRegexp  string        `arg:"--tests" help:"regex of tests to fill" default:".*"`
+	Workers      int           `arg:"--workers" help:"number of clients filling tests which don't modify the client state in parallel" default:"1"`
+	Timeout      time.Duration `arg:"--timeout" help:"timeout of each test, overrides the timeouts set by tests (default: 3s)"`
+	Prune        bool          `arg:"--prune" help:"remove test fixtures of tests which don't exist anymore"`
+	Check        bool          `arg:"--check" help:"fail if the filled test fixtures differ from the fixtures in the output directory, without changing them"`
+
+	Verify *VerifyCmd `arg:"subcommand:verify" help:"replay the test fixtures against the client and compare its responses"`
 
 	tests       *regexp.Regexp
 	logLevelInt int
+	client      *clientConfig
 }
 
 type ArgsKey struct{}
@@ -46,11 +55,24 @@ func main() {
 	if args.tests, err = regexp.Compile(args.TestsRegexp); err != nil {
 		exit(err)
 	}
+	if args.Workers < 1 {
+		exit(fmt.Errorf("invalid number of workers: %d", args.Workers))
+	}
+	if args.Timeout < 0 {
+		exit(fmt.Errorf("invalid timeout: %v", args.Timeout))
+	}
+	if args.client, err = args.clientConfig(); err != nil {
+		exit(err)
+	}
 
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, ARGS, &args)
 
-	if err := runGenerator(ctx); err != nil {
+	run := runGenerator
+	if args.Verify != nil {
+		run = runVerify
+	}
+	if err := run(ctx); err != nil {
 		exit(err)
 	}
 }
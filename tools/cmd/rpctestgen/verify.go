@@ -0,0 +1,582 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-apis/tools/testgen"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// VerifyCmd replays the test fixtures against a client.
+type VerifyCmd struct {
+	TestsDir string `arg:"--tests-dir" help:"path to tests directory" default:"tests"`
+	SpecPath string `arg:"--spec" help:"path to the spec, required to verify speconly tests"`
+	Format   string `arg:"--format" help:"output format (text or json)" default:"text"`
+}
+
+// speconlyComment marks fixtures whose responses are only checked against the
+// spec. runGenerator writes it.
+const speconlyComment = "// speconly:"
+
+// runVerify replays the requests of the test fixtures against the specified
+// client, and compares its responses with the responses of the fixtures.
+//
+// The responses must be equal as JSON values, except for tests marked speconly,
+// where the response must only be valid according to the spec. The tests are
+// replayed in the order they are filled in, because some of them modify the
+// state of the client. Tests without a fixture, and tests of methods the client
+// doesn't support, are reported as skipped.
+// Fixtures without a generator, e.g. written by hand or left over from a
+// removed test, are replayed last.
+func runVerify(ctx context.Context) error {
+	var (
+		args = ctx.Value(ARGS).(*Args)
+		cmd  = args.Verify
+	)
+	if cmd.Format != "text" && cmd.Format != "json" {
+		return fmt.Errorf("unknown output format: %s", cmd.Format)
+	}
+	// The chain is copied into the tests directory when filling.
+	if args.ChainDir == "" {
+		args.ChainDir = cmd.TestsDir
+	}
+	var schemas map[string]*jsonschema.Schema
+	if cmd.SpecPath != "" {
+		var err error
+		if schemas, err = loadResultSchemas(cmd.SpecPath); err != nil {
+			return err
+		}
+	}
+
+	// Start Ethereum client.
+	client, err := spawnClient(ctx, args)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	err = client.AfterStart(ctx)
+	if err != nil {
+		return err
+	}
+
+	r := new(verifyReport)
+	clientDriver := drivers[args.client.Type]
+	verify := func(res *verifyResult, timeout time.Duration) error {
+		if !clientDriver.supports(res.Method) {
+			res.Unsupported = true
+			r.results = append(r.results, res)
+			return nil
+		}
+		fx, err := readFixture(res.File)
+		if errors.Is(err, os.ErrNotExist) {
+			res.Missing = true
+			r.results = append(r.results, res)
+			return nil
+		}
+		var fe *fixtureError
+		if errors.As(err, &fe) {
+			// A malformed fixture fails its test only.
+			res.Failures = append(res.Failures, &verifyFailure{Line: fe.line, Message: fe.Error()})
+			r.results = append(r.results, res)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if args.Verbose {
+			fmt.Fprintln(os.Stderr, "verifying", res.File)
+		}
+		res.SpecOnly = fx.speconly
+
+		// Fail test if requests exceed timeout.
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		for _, ex := range fx.exchanges {
+			if err := verifyExchange(ctx, client.HttpAddr(), ex, fx.speconly, schemas); err != nil {
+				res.Failures = append(res.Failures, &verifyFailure{Line: ex.line, Message: err.Error()})
+			}
+		}
+		res.Pass = len(res.Failures) == 0
+		r.results = append(r.results, res)
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, methodTest := range testgen.AllMethods {
+		// Skip tests that don't match regexp.
+		if !args.tests.MatchString(methodTest.Name) {
+			continue
+		}
+		for _, test := range methodTest.Tests {
+			filename := filepath.Join(cmd.TestsDir, methodTest.Name, test.Name+".io")
+			// Tests sharing a name share the fixture, replay it once.
+			if seen[filename] {
+				continue
+			}
+			seen[filename] = true
+			res := &verifyResult{Method: methodTest.Name, Test: test.Name, File: filename}
+			if err := verify(res, args.testTimeout(test)); err != nil {
+				return err
+			}
+		}
+	}
+	stale, err := staleFixtures(cmd.TestsDir)
+	if err != nil {
+		return err
+	}
+	for _, filename := range stale {
+		rel, err := filepath.Rel(cmd.TestsDir, filename)
+		if err != nil {
+			return err
+		}
+		method, test, _ := strings.Cut(strings.TrimSuffix(filepath.ToSlash(rel), ".io"), "/")
+		if !args.tests.MatchString(method) {
+			continue
+		}
+		res := &verifyResult{Method: method, Test: test, File: filename, Stale: true}
+		if err := verify(res, args.testTimeout(testgen.Test{})); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Format == "json" {
+		if err := r.writeJSON(os.Stdout); err != nil {
+			return err
+		}
+	} else {
+		r.writeText(os.Stdout)
+	}
+	return r.err()
+}
+
+// fixture is a test fixture read from a .io file.
+type fixture struct {
+	speconly  bool
+	exchanges []*exchange
+}
+
+// exchange is a request line of a fixture and the response line following it.
+// The response is nil for notifications.
+type exchange struct {
+	line     int
+	request  []byte
+	response []byte
+}
+
+// readFixture reads the request-response exchanges of a test fixture. A
+// malformed fixture is reported as a *fixtureError.
+func readFixture(filename string) (*fixture, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fx := new(fixture)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, speconlyComment):
+			fx.speconly = true
+		case len(line) == 0 || strings.HasPrefix(line, "//"):
+			// Skip comments, blank lines.
+			continue
+		case strings.HasPrefix(line, ">> "):
+			fx.exchanges = append(fx.exchanges, &exchange{line: i + 1, request: []byte(line[3:])})
+		case strings.HasPrefix(line, "<< "):
+			if len(fx.exchanges) == 0 || fx.exchanges[len(fx.exchanges)-1].response != nil {
+				return nil, &fixtureError{line: i + 1, msg: "response w/o corresponding request"}
+			}
+			fx.exchanges[len(fx.exchanges)-1].response = []byte(line[3:])
+		default:
+			return nil, &fixtureError{line: i + 1, msg: "unexpected line: " + line}
+		}
+	}
+	return fx, nil
+}
+
+// fixtureError is a malformed line of a fixture.
+type fixtureError struct {
+	line int
+	msg  string
+}
+
+func (e *fixtureError) Error() string {
+	return "invalid fixture: " + e.msg
+}
+
+// verifyExchange sends the request of ex to the client at addr and checks the
+// client's response against the response of the fixture.
+func verifyExchange(ctx context.Context, addr string, ex *exchange, speconly bool, schemas map[string]*jsonschema.Schema) error {
+	got, err := post(ctx, addr, ex.request)
+	if err != nil {
+		return err
+	}
+	if ex.response == nil {
+		// Notifications are not answered.
+		return nil
+	}
+	if len(got) == 0 {
+		return errors.New("no response")
+	}
+	var gotv, wantv any
+	if err := unmarshalNumbers(got, &gotv); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	if err := unmarshalNumbers(ex.response, &wantv); err != nil {
+		return fmt.Errorf("invalid response in fixture: %v", err)
+	}
+	if speconly {
+		return checkSpecOnly(ex.request, wantv, gotv, schemas)
+	}
+	return diffJSON(sortBatch(wantv), sortBatch(gotv), "")
+}
+
+// post sends a JSON-RPC request to addr and returns the response body.
+func post(ctx context.Context, addr string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(respBody), nil
+}
+
+// checkSpecOnly checks the response of a speconly test. Only the outcome must
+// match the fixture: for each request answered with a result in the fixture,
+// the client must answer with a result which is valid according to the spec,
+// and for each request answered with an error, the client must answer with an
+// error.
+func checkSpecOnly(request []byte, want, got any, schemas map[string]*jsonschema.Schema) error {
+	if schemas == nil {
+		return errors.New("speconly test requires --spec")
+	}
+	var reqv any
+	if err := unmarshalNumbers(request, &reqv); err != nil {
+		return fmt.Errorf("invalid request in fixture: %v", err)
+	}
+	reqs, wants, gots := messageList(reqv), messageList(want), messageList(got)
+	for _, w := range wants {
+		id := messageID(w)
+		g := findMessage(gots, id)
+		if g == nil {
+			return fmt.Errorf("no response for id %s", id)
+		}
+		_, wantErr := w["error"]
+		_, gotErr := g["error"]
+		switch {
+		case wantErr && !gotErr:
+			return fmt.Errorf("id %s: got result, want error", id)
+		case !wantErr && gotErr:
+			return fmt.Errorf("id %s: got error %s, want result", id, compactValue(g["error"]))
+		case wantErr:
+			continue
+		}
+		method, _ := findMessage(reqs, id)["method"].(string)
+		schema, ok := schemas[method]
+		if !ok {
+			return fmt.Errorf("method %s not found in spec", method)
+		}
+		if err := schema.Validate(g["result"]); err != nil {
+			return fmt.Errorf("id %s: invalid result: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// messageList returns the messages of a single message or a batch.
+func messageList(v any) []map[string]any {
+	var msgs []map[string]any
+	switch v := v.(type) {
+	case map[string]any:
+		msgs = append(msgs, v)
+	case []any:
+		for _, item := range v {
+			if msg, ok := item.(map[string]any); ok {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	return msgs
+}
+
+// messageID returns the id of a JSON-RPC message as JSON.
+func messageID(msg map[string]any) string {
+	return compactValue(msg["id"])
+}
+
+// findMessage returns the message with the given id, or nil.
+func findMessage(msgs []map[string]any, id string) map[string]any {
+	for _, msg := range msgs {
+		if messageID(msg) == id {
+			return msg
+		}
+	}
+	return nil
+}
+
+// sortBatch orders the responses of a batch by id, since they may be sent in
+// any order. Other values are returned as they are.
+func sortBatch(v any) any {
+	batch, ok := v.([]any)
+	if !ok {
+		return v
+	}
+	sorted := slices.Clone(batch)
+	slices.SortStableFunc(sorted, func(a, b any) int {
+		ma, _ := a.(map[string]any)
+		mb, _ := b.(map[string]any)
+		return strings.Compare(messageID(ma), messageID(mb))
+	})
+	return sorted
+}
+
+// diffJSON returns an error describing the first difference between two JSON
+// values, or nil if they are equal. Numbers must be represented as json.Number.
+func diffJSON(want, got any, pointer string) error {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := slices.Collect(maps.Keys(w))
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			p := pointer + "/" + k
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case !inGot:
+				return fmt.Errorf("response differs at %s: missing, want %s", p, compactValue(wv))
+			case !inWant:
+				return fmt.Errorf("response differs at %s: got unexpected %s", p, compactValue(gv))
+			}
+			if err := diffJSON(wv, gv, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			break
+		}
+		if len(w) != len(g) {
+			return fmt.Errorf("response differs at %s: got %d items, want %d", pointerOrRoot(pointer), len(g), len(w))
+		}
+		for i := range w {
+			if err := diffJSON(w[i], g[i], pointer+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Errorf("response differs at %s: got %s, want %s", pointerOrRoot(pointer), compactValue(got), compactValue(want))
+	}
+	return nil
+}
+
+func pointerOrRoot(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
+}
+
+// compactValue returns v as JSON, shortened for reports.
+func compactValue(v any) string {
+	const limit = 80
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(b) > limit {
+		return string(b[:limit]) + "..."
+	}
+	return string(b)
+}
+
+// unmarshalNumbers decodes JSON into v, keeping numbers as json.Number so
+// that they are compared exactly.
+func unmarshalNumbers(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// loadResultSchemas compiles the result schemas of the methods in an OpenRPC
+// document. The document may be dereferenced or use references to components.
+func loadResultSchemas(filename string) (map[string]*jsonschema.Schema, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read spec: %v", err)
+	}
+	var doc struct {
+		Methods []struct {
+			Name   string `json:"name"`
+			Result struct {
+				Ref string `json:"$ref"`
+			} `json:"result"`
+		} `json:"methods"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("unable to read spec: %v", err)
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	url := "file://" + filepath.ToSlash(abs)
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2019
+	if err := compiler.AddResource(url, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("unable to load spec: %v", err)
+	}
+	schemas := make(map[string]*jsonschema.Schema, len(doc.Methods))
+	for i, method := range doc.Methods {
+		ptr := fmt.Sprintf("/methods/%d/result", i)
+		if method.Result.Ref != "" {
+			ptr = strings.TrimPrefix(method.Result.Ref, "#")
+		}
+		if schemas[method.Name], err = compiler.Compile(url + "#" + ptr + "/schema"); err != nil {
+			return nil, fmt.Errorf("%s: %v", method.Name, err)
+		}
+	}
+	return schemas, nil
+}
+
+// verifyFailure is a mismatch between a response of the client and the
+// fixture.
+type verifyFailure struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// verifyResult is the outcome of replaying a single test. Tests whose fixture
+// is missing, or whose method the client doesn't support, are skipped; they
+// neither pass nor fail.
+type verifyResult struct {
+	Method      string           `json:"method"`
+	Test        string           `json:"test"`
+	File        string           `json:"file"`
+	SpecOnly    bool             `json:"speconly"`
+	Missing     bool             `json:"missing,omitempty"`     // no fixture, the test was skipped
+	Unsupported bool             `json:"unsupported,omitempty"` // method not supported by the client, the test was skipped
+	Stale       bool             `json:"stale,omitempty"`       // fixture without a generator
+	Pass        bool             `json:"pass"`
+	Failures    []*verifyFailure `json:"failures,omitempty"`
+}
+
+func (res *verifyResult) skipped() bool {
+	return res.Missing || res.Unsupported
+}
+
+// verifyReport collects the results of a verify run.
+type verifyReport struct {
+	results []*verifyResult
+}
+
+func (r *verifyReport) failedTests() int {
+	failed := 0
+	for _, res := range r.results {
+		if !res.Pass && !res.skipped() {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (r *verifyReport) skippedTests() int {
+	skipped := 0
+	for _, res := range r.results {
+		if res.skipped() {
+			skipped++
+		}
+	}
+	return skipped
+}
+
+// ranTests returns the number of tests which were replayed.
+func (r *verifyReport) ranTests() int {
+	return len(r.results) - r.skippedTests()
+}
+
+// err returns an error summarizing the run, or nil if all tests passed.
+func (r *verifyReport) err() error {
+	if failed := r.failedTests(); failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, r.ranTests())
+	}
+	return nil
+}
+
+// writeText writes the result of each test, with the failures of failed tests.
+func (r *verifyReport) writeText(w io.Writer) {
+	for _, res := range r.results {
+		note := ""
+		if res.Stale {
+			note = " (no generator)"
+		}
+		switch {
+		case res.Missing:
+			fmt.Fprintf(w, "SKIP %s/%s (fixture missing)\n", res.Method, res.Test)
+		case res.Unsupported:
+			fmt.Fprintf(w, "SKIP %s/%s (not supported by client)\n", res.Method, res.Test)
+		case res.Pass:
+			fmt.Fprintf(w, "PASS %s/%s%s\n", res.Method, res.Test, note)
+		default:
+			fmt.Fprintf(w, "FAIL %s/%s%s\n", res.Method, res.Test, note)
+			for _, f := range res.Failures {
+				fmt.Fprintf(w, "    %s:%d: %s\n", res.File, f.Line, f.Message)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d of %d tests passed", r.ranTests()-r.failedTests(), r.ranTests())
+	if skipped := r.skippedTests(); skipped > 0 {
+		fmt.Fprintf(w, ", %d skipped", skipped)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeJSON writes the report as a JSON object for consumption by CI.
+func (r *verifyReport) writeJSON(w io.Writer) error {
+	out := struct {
+		Passed  int             `json:"passed"`
+		Failed  int             `json:"failed"`
+		Skipped int             `json:"skipped"`
+		Tests   []*verifyResult `json:"tests"`
+	}{r.ranTests() - r.failedTests(), r.failedTests(), r.skippedTests(), r.results}
+	if out.Tests == nil {
+		out.Tests = []*verifyResult{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func decodeJSON(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := unmarshalNumbers([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		want, got string
+		err       string
+	}{
+		{"equal", `{"a":1,"b":[1,"x"]}`, `{"b":[1,"x"],"a":1}`, ""},
+		{"number representation", `{"a":1}`, `{"a":1.0}`, "response differs at /a: got 1.0, want 1"},
+		{"value", `{"a":{"b":"0x1"}}`, `{"a":{"b":"0x2"}}`, `response differs at /a/b: got "0x2", want "0x1"`},
+		{"missing field", `{"a":1,"b":2}`, `{"a":1}`, "response differs at /b: missing, want 2"},
+		{"unexpected field", `{"a":1}`, `{"a":1,"b":2}`, "response differs at /b: got unexpected 2"},
+		{"array length", `[1,2]`, `[1]`, "response differs at /: got 1 items, want 2"},
+		{"array item", `{"a":[1,2]}`, `{"a":[1,3]}`, "response differs at /a/1: got 3, want 2"},
+		{"type", `{"a":[]}`, `{"a":{}}`, "response differs at /a: got {}, want []"},
+		{"null", `{"a":null}`, `{"a":"0x0"}`, `response differs at /a: got "0x0", want null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := diffJSON(decodeJSON(t, tt.want), decodeJSON(t, tt.got), "")
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.err != "" && (err == nil || err.Error() != tt.err):
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSortBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		in, want string
+	}{
+		{"single response", `{"id":2,"result":"0x1"}`, `{"id":2,"result":"0x1"}`},
+		{"ordered batch", `[{"id":1},{"id":2}]`, `[{"id":1},{"id":2}]`},
+		{"unordered batch", `[{"id":3},{"id":1},{"id":2}]`, `[{"id":1},{"id":2},{"id":3}]`},
+		{"ids compared as JSON", `[{"id":"b"},{"id":"a"},{"id":10}]`, `[{"id":"a"},{"id":"b"},{"id":10}]`},
+		{"equal ids keep order", `[{"x":2},{"x":1}]`, `[{"x":2},{"x":1}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := decodeJSON(t, tt.in)
+			if got, want := sortBatch(in), decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReadFixture(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		speconly  bool
+		exchanges []exchange
+		errLine   int
+	}{
+		{
+			name: "exchanges",
+			content: `// gets the balance
+>> {"id":1}
+<< {"id":1,"result":"0x0"}
+
+>> {"id":2}
+<< {"id":2,"result":"0x1"}
+`,
+			exchanges: []exchange{
+				{line: 2, request: []byte(`{"id":1}`), response: []byte(`{"id":1,"result":"0x0"}`)},
+				{line: 5, request: []byte(`{"id":2}`), response: []byte(`{"id":2,"result":"0x1"}`)},
+			},
+		},
+		{
+			name: "speconly",
+			content: `// speconly: client response is only checked for schema validity.
+>> {"id":1}
+<< {"id":1,"result":"0x0"}`,
+			speconly: true,
+			exchanges: []exchange{
+				{line: 2, request: []byte(`{"id":1}`), response: []byte(`{"id":1,"result":"0x0"}`)},
+			},
+		},
+		{
+			name:      "notification",
+			content:   `>> {"method":"eth_subscription"}`,
+			exchanges: []exchange{{line: 1, request: []byte(`{"method":"eth_subscription"}`)}},
+		},
+		{
+			name:    "response without request",
+			content: "<< {\"id\":1}\n",
+			errLine: 1,
+		},
+		{
+			name:    "two responses",
+			content: ">> {\"id\":1}\n<< {\"id\":1}\n<< {\"id\":1}\n",
+			errLine: 3,
+		},
+		{
+			name:    "unexpected line",
+			content: ">> {\"id\":1}\n{\"id\":1}\n",
+			errLine: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "test.io")
+			if err := os.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			fx, err := readFixture(file)
+			if tt.errLine != 0 {
+				var fe *fixtureError
+				if !errors.As(err, &fe) {
+					t.Fatalf("got error %v, want fixture error", err)
+				}
+				if fe.line != tt.errLine {
+					t.Errorf("got error at line %d, want %d", fe.line, tt.errLine)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fx.speconly != tt.speconly {
+				t.Errorf("got speconly %v, want %v", fx.speconly, tt.speconly)
+			}
+			var got []exchange
+			for _, ex := range fx.exchanges {
+				got = append(got, *ex)
+			}
+			if !reflect.DeepEqual(got, tt.exchanges) {
+				t.Errorf("wrong exchanges:\ngot  %q\nwant %q", got, tt.exchanges)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := readFixture(filepath.Join(t.TempDir(), "test.io"))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %v, want not exist", err)
+		}
+	})
+}
+
+func TestCheckSpecOnly(t *testing.T) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", strings.NewReader(`{"type":"string","pattern":"^0x[0-9a-f]+$"}`)); err != nil {
+		t.Fatal(err)
+	}
+	schemas := map[string]*jsonschema.Schema{"eth_blockNumber": compiler.MustCompile("schema.json")}
+
+	const (
+		request = `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`
+		batch   = `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":2,"method":"eth_unknown"}]`
+	)
+	tests := []struct {
+		name      string
+		request   string
+		want, got string
+		noSpec    bool
+		err       string
+	}{
+		{
+			name:    "other valid result",
+			request: request,
+			want:    `{"id":1,"result":"0x1"}`,
+			got:     `{"id":1,"result":"0x2a"}`,
+		},
+		{
+			name:    "invalid result",
+			request: request,
+			want:    `{"id":1,"result":"0x1"}`,
+			got:     `{"id":1,"result":"42"}`,
+			err:     "id 1: invalid result",
+		},
+		{
+			name:    "error instead of result",
+			request: request,
+			want:    `{"id":1,"result":"0x1"}`,
+			got:     `{"id":1,"error":{"code":-32000,"message":"x"}}`,
+			err:     "id 1: got error",
+		},
+		{
+			name:    "result instead of error",
+			request: request,
+			want:    `{"id":1,"error":{"code":-32000,"message":"x"}}`,
+			got:     `{"id":1,"result":"0x1"}`,
+			err:     "id 1: got result, want error",
+		},
+		{
+			name:    "other error",
+			request: request,
+			want:    `{"id":1,"error":{"code":-32000,"message":"x"}}`,
+			got:     `{"id":1,"error":{"code":-32602,"message":"y"}}`,
+		},
+		{
+			name:    "batch",
+			request: batch,
+			want:    `[{"id":1,"result":"0x1"},{"id":2,"error":{"code":-32601,"message":"x"}}]`,
+			got:     `[{"id":2,"error":{"code":-32601,"message":"y"}},{"id":1,"result":"0x5"}]`,
+		},
+		{
+			name:    "batch missing response",
+			request: batch,
+			want:    `[{"id":1,"result":"0x1"},{"id":2,"error":{"code":-32601,"message":"x"}}]`,
+			got:     `[{"id":1,"result":"0x5"}]`,
+			err:     "no response for id 2",
+		},
+		{
+			name:    "method not in spec",
+			request: `{"jsonrpc":"2.0","id":1,"method":"eth_unknown"}`,
+			want:    `{"id":1,"result":"0x1"}`,
+			got:     `{"id":1,"result":"0x1"}`,
+			err:     "method eth_unknown not found in spec",
+		},
+		{
+			name:    "no spec",
+			request: request,
+			want:    `{"id":1,"result":"0x1"}`,
+			got:     `{"id":1,"result":"0x1"}`,
+			noSpec:  true,
+			err:     "speconly test requires --spec",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := schemas
+			if tt.noSpec {
+				s = nil
+			}
+			err := checkSpecOnly([]byte(tt.request), decodeJSON(t, tt.want), decodeJSON(t, tt.got), s)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifyReport(t *testing.T) {
+	r := &verifyReport{results: []*verifyResult{
+		{Method: "eth_blockNumber", Test: "simple-test", Pass: true},
+		{Method: "eth_call", Test: "call-simple", File: "eth_call/call-simple.io", Failures: []*verifyFailure{{Line: 2, Message: "invalid fixture: unexpected line: x"}}},
+		{Method: "eth_getBalance", Test: "get-balance", Missing: true},
+		{Method: "testing_buildBlockV1", Test: "build-block", Unsupported: true},
+	}}
+	if got, want := r.skippedTests(), 2; got != want {
+		t.Errorf("got %d skipped tests, want %d", got, want)
+	}
+	if err := r.err(); err == nil || err.Error() != "1 of 2 tests failed" {
+		t.Errorf("wrong error: %v", err)
+	}
+	var buf strings.Builder
+	r.writeText(&buf)
+	want := `PASS eth_blockNumber/simple-test
+FAIL eth_call/call-simple
+    eth_call/call-simple.io:2: invalid fixture: unexpected line: x
+SKIP eth_getBalance/get-balance (fixture missing)
+SKIP testing_buildBlockV1/build-block (not supported by client)
+
+1 of 2 tests passed, 2 skipped
+`
+	if buf.String() != want {
+		t.Errorf("wrong report:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
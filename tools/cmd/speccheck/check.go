@@ -1,84 +1,243 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"regexp"
+	"reflect"
 	"strings"
 
-	openrpc "github.com/open-rpc/spec-types/generated/packages/go/v1_4"
 	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
+// checkOptions configures the optional checks of checkSpec.
+type checkOptions struct {
+	ignoreMessages bool // don't require error messages to match the declared message
+	strict         bool // fail on result fields not declared by the schema
+}
+
 // checkSpec reads the schemas from the spec and test files, then validates
-// them against each other.
-func checkSpec(methods map[string]*methodSchema, rts []*roundTrip, re *regexp.Regexp) error {
+// them against each other. All failures are collected in the returned report.
+func checkSpec(methods map[string]*methodSchema, rts []*roundTrip, opts checkOptions) *report {
+	r := new(report)
 	for _, rt := range rts {
-		method, ok := methods[rt.method]
-		if !ok {
-			return fmt.Errorf("undefined method: %s", rt.method)
+		r.checked++
+		for _, err := range rt.exchangeErrs {
+			r.add(rt, "response", err)
 		}
-		// skip validator of test if name includes "invalid" as the schema
-		// doesn't yet support it.
-		// TODO(matt): create error schemas.
-		if strings.Contains(rt.name, "invalid") {
+		if rt.request.invalid {
+			r.add(rt, "response", checkInvalidRequest(rt.response))
 			continue
 		}
-		if len(method.params) < len(rt.params) {
-			return fmt.Errorf("%s: too many parameters", method.name)
+		r.add(rt, "request", checkVersion(rt.request))
+		wellFormed := true
+		if rt.response != nil {
+			r.add(rt, "response", checkVersion(rt.response))
+			r.add(rt, "response", checkID(rt.request, rt.response))
+			if err := checkMembers(rt.response); err != nil {
+				r.add(rt, "response", err)
+				wellFormed = false
+			}
+		}
+		method, ok := methods[rt.method]
+		if !ok {
+			r.add(rt, "method", fmt.Errorf("undefined method: %s", rt.method))
+			continue
 		}
-		// Validate each parameter value against their respective schema.
-		for i, cd := range method.params {
-			if len(rt.params) <= i {
-				if !cd.required {
-					// skip missing optional values
-					continue
-				}
-				return fmt.Errorf("missing required parameter %s.param[%d]", rt.method, i)
+		// Tests with "invalid" in their name are negative tests of the spec:
+		// at least one parameter must be rejected by the schema, and the
+		// client must respond with an error. Tests annotated with validparams
+		// are checked like any other test.
+		perrs := checkParams(method, rt)
+		invalid := strings.Contains(rt.name, "invalid") && !rt.validParams
+		if invalid {
+			if len(perrs) == 0 {
+				r.add(rt, "params", fmt.Errorf("test is marked invalid, but all parameters are valid"))
+			}
+			if rt.response != nil && wellFormed && rt.response.Error == nil {
+				r.add(rt, "response", fmt.Errorf("test is marked invalid, but response is not an error"))
+				continue
 			}
-			if err := validate(&method.params[i].schema, rt.params[i], fmt.Sprintf("%s.param[%d]", rt.method, i)); err != nil {
-				return fmt.Errorf("unable to validate parameter in %s: %s", rt.name, err)
+		} else {
+			for _, perr := range perrs {
+				r.add(rt, perr.location, perr.err)
+			}
+		}
+		if rt.response == nil {
+			if !rt.isNotification() {
+				r.add(rt, "response", fmt.Errorf("missing response"))
 			}
+			continue
+		}
+		if !wellFormed {
+			continue
 		}
-		if rt.response.Result == nil && rt.response.Error != nil {
-			// skip validation of errors, they haven't been standardized
+		if e := rt.response.Error; e != nil {
+			declared, err := checkError(method, e, !opts.ignoreMessages)
+			if err != nil {
+				r.add(rt, "error", err)
+			} else if declared != nil && declared.data != nil {
+				r.add(rt, "error.data", validateErrorData(declared, e))
+			}
 			continue
 		}
-		if err := validate(&method.result.schema, rt.response.Result, fmt.Sprintf("%s.result", rt.method)); err != nil {
-			// Print out the value and schema if there is an error to further debug.
-			buf, _ := json.Marshal(method.result.schema)
-			fmt.Println(string(buf))
-			fmt.Println(string(rt.response.Result))
-			fmt.Println()
-			return fmt.Errorf("invalid result %s\n%#v", rt.name, err)
+		if err := validate(method.result.schema, rt.response.Result); err != nil {
+			r.add(rt, "result", err)
+		} else if opts.strict {
+			r.addUndeclared(rt, "result", undeclaredFields(method.result.schema, rt.response.Result))
 		}
 	}
+	return r
+}
 
-	fmt.Println("all passing.")
+// checkVersion checks that the message declares JSON-RPC version "2.0".
+func checkVersion(msg *jsonrpcMessage) error {
+	if msg.Version == nil {
+		return fmt.Errorf("missing jsonrpc member")
+	}
+	if !bytes.Equal(compactJSON(msg.Version), []byte(`"2.0"`)) {
+		return fmt.Errorf(`jsonrpc member is %s, want "2.0"`, msg.Version)
+	}
 	return nil
 }
 
-// validateParam validates the provided value against schema using the url base.
-func validate(schema *openrpc.JSONSchemaObject, val []byte, url string) error {
-	// Set $schema explicitly to force jsonschema to use draft 2019-09.
-	draft := openrpc.Schema("https://json-schema.org/draft/2019-09/schema")
-	schema.Schema = &draft
+// checkID checks that the response id equals the request id. The ids must also
+// have the same JSON type, e.g. a numeric request id must not be echoed back
+// as a string.
+func checkID(req, resp *jsonrpcMessage) error {
+	if resp.ID == nil {
+		return fmt.Errorf("missing id")
+	}
+	var reqID, respID interface{}
+	if err := json.Unmarshal(req.ID, &reqID); err != nil {
+		return fmt.Errorf("invalid request id %s: %v", req.ID, err)
+	}
+	if err := json.Unmarshal(resp.ID, &respID); err != nil {
+		return fmt.Errorf("invalid response id %s: %v", resp.ID, err)
+	}
+	if !reflect.DeepEqual(reqID, respID) {
+		return fmt.Errorf("id %s does not match request id %s", resp.ID, req.ID)
+	}
+	return nil
+}
 
-	// Compile schema.
-	b, err := json.Marshal(schema)
-	if err != nil {
-		return fmt.Errorf("unable to marshal schema to json")
+// checkInvalidRequest checks the response to a request which is not a request
+// object. JSON-RPC 2.0 requires an Invalid Request error with a null id.
+func checkInvalidRequest(resp *jsonrpcMessage) error {
+	if resp == nil {
+		return fmt.Errorf("missing response to invalid request")
+	}
+	if err := checkVersion(resp); err != nil {
+		return err
+	}
+	if !bytes.Equal(compactJSON(resp.ID), []byte("null")) {
+		return fmt.Errorf("id %s of response to invalid request, want null", resp.ID)
+	}
+	if err := checkMembers(resp); err != nil {
+		return err
+	}
+	if resp.Error == nil {
+		return fmt.Errorf("invalid request answered by result")
 	}
-	s, err := jsonschema.CompileString(url, string(b))
+	if resp.Error.Code != -32600 {
+		return fmt.Errorf("invalid request answered by error code %d, want -32600", resp.Error.Code)
+	}
+	return nil
+}
+
+// checkMembers checks that the response carries exactly one of result and
+// error. A result of null is a result, but an error must be an object.
+func checkMembers(resp *jsonrpcMessage) error {
+	hasResult := resp.Result != nil
+	switch {
+	case hasResult && resp.hasError:
+		return fmt.Errorf("has both result and error")
+	case !hasResult && !resp.hasError:
+		return fmt.Errorf("has neither result nor error")
+	case resp.hasError && resp.Error == nil:
+		return fmt.Errorf("error member is null")
+	}
+	return nil
+}
+
+// paramError is a request parameter which doesn't conform to the spec.
+type paramError struct {
+	location string
+	err      error
+}
+
+// checkParams validates each parameter value of the request against their
+// respective schema.
+func checkParams(method *methodSchema, rt *roundTrip) []paramError {
+	var errs []paramError
+	if len(method.params) < len(rt.params) {
+		errs = append(errs, paramError{"params", fmt.Errorf("too many parameters")})
+	}
+	for i, cd := range method.params {
+		location := fmt.Sprintf("params[%d]", i)
+		if len(rt.params) <= i {
+			if cd.required {
+				errs = append(errs, paramError{location, fmt.Errorf("missing required parameter %s", cd.name)})
+			}
+			// skip missing optional values
+			continue
+		}
+		if err := validate(cd.schema, rt.params[i]); err != nil {
+			errs = append(errs, paramError{location, err})
+		}
+	}
+	return errs
+}
+
+// isReservedErrorCode reports whether code is one of the errors pre-defined by
+// the JSON-RPC 2.0 specification. Any method may fail with these, so they don't
+// have to be declared. Server errors (-32099 to -32000) are defined by the
+// implementation and must be declared by the method.
+func isReservedErrorCode(code int) bool {
+	return code == -32700 || (code >= -32603 && code <= -32600)
+}
+
+// checkError checks an error response against the errors declared by the
+// method and returns the matching declaration. The code must be declared unless
+// it is reserved by JSON-RPC, in which case no declaration is returned. If
+// checkMessage is set, the message must also start with the declared message
+// (clients commonly append details).
+func checkError(method *methodSchema, e *jsonError, checkMessage bool) (*errorSchema, error) {
+	var declared *errorSchema
+	for _, es := range method.errors {
+		if es.code == e.Code {
+			declared = es
+			break
+		}
+	}
+	if declared == nil {
+		if isReservedErrorCode(e.Code) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("undeclared error code %d (%q)", e.Code, e.Message)
+	}
+	if checkMessage && !strings.HasPrefix(strings.ToLower(e.Message), strings.ToLower(declared.message)) {
+		return nil, fmt.Errorf("error code %d: message %q does not match %q", e.Code, e.Message, declared.message)
+	}
+	return declared, nil
+}
+
+// validateErrorData validates the data of an error response against the data
+// schema of the declared error.
+func validateErrorData(declared *errorSchema, e *jsonError) error {
+	if e.Data == nil {
+		return fmt.Errorf("error code %d: missing data", e.Code)
+	}
+	data, err := json.Marshal(e.Data)
 	if err != nil {
 		return err
 	}
+	return validate(declared.data, data)
+}
 
-	// Validate value
+// validate validates the provided value against schema.
+func validate(schema *jsonschema.Schema, val []byte) error {
 	var x interface{}
 	json.Unmarshal(val, &x)
-	if err := s.Validate(x); err != nil {
-		return err
-	}
-	return nil
+	return schema.Validate(x)
 }
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testSpec declares a method with an error carrying data and an error without.
+const testSpec = `{
+  "openrpc": "1.2.4",
+  "info": {"title": "test", "version": "1.0.0"},
+  "methods": [{
+    "name": "eth_test",
+    "params": [{"name": "block", "required": true, "schema": {"type": "string", "pattern": "^0x[0-9a-f]+$"}}],
+    "result": {"name": "result", "schema": {"type": "string"}},
+    "errors": [
+      {"code": 3, "message": "execution reverted", "data": {"type": "string", "pattern": "^0x"}},
+      {"code": 4444, "message": "Pruned history unavailable"}
+    ]
+  }]
+}`
+
+// readTestSpec parses spec from a temporary file.
+func readTestSpec(t *testing.T, spec string) map[string]*methodSchema {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "openrpc.json")
+	if err := os.WriteFile(file, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	methods, err := parseSpec(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return methods
+}
+
+// writeTestFile writes a test fixture to a temporary directory.
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "test.io")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestIsReservedErrorCode(t *testing.T) {
+	for code, want := range map[int]bool{
+		-32700: true,
+		-32603: true,
+		-32601: true,
+		-32600: true,
+		-32604: false,
+		-32099: false,
+		-32000: false,
+		-32768: false,
+		3:      false,
+		4444:   false,
+	} {
+		if got := isReservedErrorCode(code); got != want {
+			t.Errorf("isReservedErrorCode(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestCheckError(t *testing.T) {
+	method := readTestSpec(t, testSpec)["eth_test"]
+	tests := []struct {
+		name         string
+		err          jsonError
+		checkMessage bool
+		wantErr      string // part of the error, empty if the error is valid
+	}{
+		{
+			name: "declared",
+			err:  jsonError{Code: 4444, Message: "Pruned history unavailable"},
+		},
+		{
+			name:    "undeclared",
+			err:     jsonError{Code: 5, Message: "boom"},
+			wantErr: `undeclared error code 5 ("boom")`,
+		},
+		{
+			name: "reserved",
+			err:  jsonError{Code: -32602, Message: "invalid argument 0"},
+		},
+		{
+			name:         "reserved with messages",
+			err:          jsonError{Code: -32602, Message: "invalid argument 0"},
+			checkMessage: true,
+		},
+		{
+			name: "other message",
+			err:  jsonError{Code: 4444, Message: "history expired"},
+		},
+		{
+			name:         "other message with messages",
+			err:          jsonError{Code: 4444, Message: "history expired"},
+			checkMessage: true,
+			wantErr:      `error code 4444: message "history expired" does not match "Pruned history unavailable"`,
+		},
+		{
+			name:         "message with details",
+			err:          jsonError{Code: 4444, Message: "pruned history unavailable: block 1"},
+			checkMessage: true,
+		},
+		{
+			name: "valid data",
+			err:  jsonError{Code: 3, Message: "execution reverted", Data: "0x01"},
+		},
+		{
+			name:    "missing data",
+			err:     jsonError{Code: 3, Message: "execution reverted"},
+			wantErr: "error code 3: missing data",
+		},
+		{
+			name:    "invalid data",
+			err:     jsonError{Code: 3, Message: "execution reverted", Data: "01"},
+			wantErr: "does not match pattern",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			declared, err := checkError(method, &tt.err, tt.checkMessage)
+			if err == nil && declared != nil && declared.data != nil {
+				err = validateErrorData(declared, &tt.err)
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("no error, want %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("error = %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckSpecInvalidTests(t *testing.T) {
+	methods := readTestSpec(t, testSpec)
+	tests := []struct {
+		name    string
+		test    string
+		fixture string
+		want    []string // locations of the expected failures
+	}{
+		{
+			name: "rejected params",
+			test: "eth_test/call-invalid-block",
+			fixture: `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["latest"]}
+<< {"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid argument 0"}}`,
+		},
+		{
+			name: "valid params",
+			test: "eth_test/call-invalid-block",
+			fixture: `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0x1"]}
+<< {"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid argument 0"}}`,
+			want: []string{"params"},
+		},
+		{
+			name: "successful response",
+			test: "eth_test/call-invalid-block",
+			fixture: `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["latest"]}
+<< {"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+			want: []string{"response"},
+		},
+		{
+			name: "validparams annotation",
+			test: "eth_test/call-invalid-block",
+			fixture: `// validparams: request parameters are valid, the request fails for other reasons.
+>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0x1"]}
+<< {"jsonrpc":"2.0","id":1,"error":{"code":4444,"message":"Pruned history unavailable"}}`,
+		},
+		{
+			name: "validparams annotation with rejected params",
+			test: "eth_test/call-invalid-block",
+			fixture: `// validparams: request parameters are valid, the request fails for other reasons.
+>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["latest"]}
+<< {"jsonrpc":"2.0","id":1,"error":{"code":4444,"message":"Pruned history unavailable"}}`,
+			want: []string{"params[0]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rts, err := readTest(tt.test, writeTestFile(t, tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := checkSpec(methods, rts, checkOptions{})
+			var got []string
+			for _, f := range r.failures {
+				got = append(got, f.Location)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("failures at %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// decodeMessage decodes a JSON-RPC message as found in a fixture.
+func decodeMessage(t *testing.T, s string) *jsonrpcMessage {
+	t.Helper()
+	var msg jsonrpcMessage
+	if err := json.Unmarshal([]byte(s), &msg); err != nil {
+		t.Fatal(err)
+	}
+	return &msg
+}
+
+func TestCheckEnvelope(t *testing.T) {
+	const req = `{"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0x1"]}`
+	tests := []struct {
+		name    string
+		req     string
+		resp    string
+		wantErr string // part of the error, empty if the envelope is valid
+	}{
+		{name: "result", resp: `{"jsonrpc":"2.0","id":1,"result":"0x1"}`},
+		{name: "null result", resp: `{"jsonrpc":"2.0","id":1,"result":null}`},
+		{name: "error", resp: `{"jsonrpc":"2.0","id":1,"error":{"code":4444,"message":"Pruned history unavailable"}}`},
+		{name: "string id", req: `{"jsonrpc":"2.0","id":"a","method":"eth_test"}`, resp: `{"jsonrpc":"2.0","id":"a","result":"0x1"}`},
+		{name: "missing version", resp: `{"id":1,"result":"0x1"}`, wantErr: "missing jsonrpc member"},
+		{name: "wrong version", resp: `{"jsonrpc":"1.0","id":1,"result":"0x1"}`, wantErr: `jsonrpc member is "1.0", want "2.0"`},
+		{name: "numeric version", resp: `{"jsonrpc":2.0,"id":1,"result":"0x1"}`, wantErr: `jsonrpc member is 2.0, want "2.0"`},
+		{name: "missing id", resp: `{"jsonrpc":"2.0","result":"0x1"}`, wantErr: "missing id"},
+		{name: "other id", resp: `{"jsonrpc":"2.0","id":2,"result":"0x1"}`, wantErr: "id 2 does not match request id 1"},
+		{name: "id type", resp: `{"jsonrpc":"2.0","id":"1","result":"0x1"}`, wantErr: `id "1" does not match request id 1`},
+		{name: "result and error", resp: `{"jsonrpc":"2.0","id":1,"result":"0x1","error":{"code":4444,"message":"x"}}`, wantErr: "has both result and error"},
+		{name: "no result or error", resp: `{"jsonrpc":"2.0","id":1}`, wantErr: "has neither result nor error"},
+		{name: "null error", resp: `{"jsonrpc":"2.0","id":1,"error":null}`, wantErr: "error member is null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqJSON := tt.req
+			if reqJSON == "" {
+				reqJSON = req
+			}
+			request, response := decodeMessage(t, reqJSON), decodeMessage(t, tt.resp)
+			err := errors.Join(checkVersion(response), checkID(request, response), checkMembers(response))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("no error, want %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("error = %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckSpecMessages(t *testing.T) {
+	methods := readTestSpec(t, testSpec)
+	rts, err := readTest("eth_test/pruned", writeTestFile(t, `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0x1"]}
+<< {"jsonrpc":"2.0","id":1,"error":{"code":4444,"message":"history expired"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := checkSpec(methods, rts, checkOptions{}); len(r.failures) != 1 {
+		t.Errorf("got %d failures by default, want 1", len(r.failures))
+	}
+	if r := checkSpec(methods, rts, checkOptions{ignoreMessages: true}); len(r.failures) != 0 {
+		t.Errorf("got %d failures when ignoring messages, want 0", len(r.failures))
+	}
+}
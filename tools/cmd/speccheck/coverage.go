@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// branchSite is a oneOf or anyOf keyword within a method schema.
+type branchSite struct {
+	location string // location of the schema containing the keyword
+	label    string
+	keyword  string
+	branches []*jsonschema.Schema
+	matched  []bool
+}
+
+// descriptorCoverage tracks the branch sites reachable from the schema of a
+// single parameter or result.
+type descriptorCoverage struct {
+	name  string
+	sites map[string]*branchSite
+}
+
+// methodCoverage tracks which parts of a method's schemas were exercised by the
+// fixtures.
+type methodCoverage struct {
+	method   *methodSchema
+	fixtures int
+	sent     []bool // whether each param was sent by any fixture
+	params   []*descriptorCoverage
+	result   *descriptorCoverage
+}
+
+// coverage computes which parts of the method schemas are exercised by the
+// round trips.
+func coverage(methods map[string]*methodSchema, rts []*roundTrip) map[string]*methodCoverage {
+	cov := make(map[string]*methodCoverage, len(methods))
+	for name, method := range methods {
+		mc := &methodCoverage{
+			method: method,
+			sent:   make([]bool, len(method.params)),
+			result: newDescriptorCoverage(method.result),
+		}
+		for _, cd := range method.params {
+			mc.params = append(mc.params, newDescriptorCoverage(cd))
+		}
+		cov[name] = mc
+	}
+	for _, rt := range rts {
+		mc, ok := cov[rt.method]
+		if !ok {
+			continue
+		}
+		mc.fixtures++
+		for i, param := range rt.params {
+			if i >= len(mc.params) {
+				break
+			}
+			mc.sent[i] = true
+			mc.params[i].cover(mc.method.params[i].schema, param)
+		}
+		if rt.response != nil && rt.response.Error == nil {
+			mc.result.cover(mc.method.result.schema, rt.response.Result)
+		}
+	}
+	return cov
+}
+
+func newDescriptorCoverage(cd *ContentDescriptor) *descriptorCoverage {
+	dc := &descriptorCoverage{name: cd.name, sites: make(map[string]*branchSite)}
+	dc.collect(cd.schema)
+	return dc
+}
+
+// collect walks the schema graph and records every oneOf and anyOf site.
+func (dc *descriptorCoverage) collect(s *jsonschema.Schema) {
+	visited := make(map[*jsonschema.Schema]bool)
+	var walk func(s *jsonschema.Schema)
+	walk = func(s *jsonschema.Schema) {
+		if s == nil || visited[s] {
+			return
+		}
+		visited[s] = true
+		for _, site := range []struct {
+			keyword  string
+			branches []*jsonschema.Schema
+		}{{"oneOf", s.OneOf}, {"anyOf", s.AnyOf}} {
+			if len(site.branches) == 0 {
+				continue
+			}
+			dc.sites[s.Location+"/"+site.keyword] = &branchSite{
+				location: s.Location,
+				label:    schemaLabel(s),
+				keyword:  site.keyword,
+				branches: site.branches,
+				matched:  make([]bool, len(site.branches)),
+			}
+		}
+		for _, sub := range subschemas(s) {
+			walk(sub)
+		}
+	}
+	walk(s)
+}
+
+// cover marks the branches matched by the JSON value.
+func (dc *descriptorCoverage) cover(s *jsonschema.Schema, raw []byte) {
+	var v interface{}
+	json.Unmarshal(raw, &v)
+	dc.coverValue(s, v)
+}
+
+func (dc *descriptorCoverage) coverValue(s *jsonschema.Schema, v interface{}) {
+	if s == nil {
+		return
+	}
+	dc.coverValue(s.Ref, v)
+	for _, sub := range s.AllOf {
+		dc.coverValue(sub, v)
+	}
+	for keyword, branches := range map[string][]*jsonschema.Schema{"oneOf": s.OneOf, "anyOf": s.AnyOf} {
+		site := dc.sites[s.Location+"/"+keyword]
+		for i, branch := range branches {
+			if branch.Validate(v) != nil {
+				continue
+			}
+			if site != nil {
+				site.matched[i] = true
+			}
+			dc.coverValue(branch, v)
+		}
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if sub, ok := s.Properties[key]; ok {
+				dc.coverValue(sub, val)
+				continue
+			}
+			for re, sub := range s.PatternProperties {
+				if re.MatchString(key) {
+					dc.coverValue(sub, val)
+				}
+			}
+			if sub, ok := s.AdditionalProperties.(*jsonschema.Schema); ok {
+				dc.coverValue(sub, val)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			dc.coverValue(itemSchema(s, i), item)
+		}
+	}
+}
+
+// subschemas returns the direct subschemas of s which describe its value or
+// the values nested within it.
+func subschemas(s *jsonschema.Schema) []*jsonschema.Schema {
+	subs := []*jsonschema.Schema{s.Ref}
+	subs = append(subs, s.AllOf...)
+	subs = append(subs, s.OneOf...)
+	subs = append(subs, s.AnyOf...)
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		subs = append(subs, s.Properties[name])
+	}
+	for _, sub := range s.PatternProperties {
+		subs = append(subs, sub)
+	}
+	if sub, ok := s.AdditionalProperties.(*jsonschema.Schema); ok {
+		subs = append(subs, sub)
+	}
+	switch items := s.Items.(type) {
+	case *jsonschema.Schema:
+		subs = append(subs, items)
+	case []*jsonschema.Schema:
+		subs = append(subs, items...)
+	}
+	if sub, ok := s.AdditionalItems.(*jsonschema.Schema); ok {
+		subs = append(subs, sub)
+	}
+	subs = append(subs, s.PrefixItems...)
+	return append(subs, s.Items2020)
+}
+
+// itemSchema returns the schema of the i'th array item.
+func itemSchema(s *jsonschema.Schema, i int) *jsonschema.Schema {
+	switch items := s.Items.(type) {
+	case *jsonschema.Schema:
+		return items
+	case []*jsonschema.Schema:
+		if i < len(items) {
+			return items[i]
+		}
+		sub, _ := s.AdditionalItems.(*jsonschema.Schema)
+		return sub
+	}
+	if i < len(s.PrefixItems) {
+		return s.PrefixItems[i]
+	}
+	return s.Items2020
+}
+
+// schemaLabel returns a human readable name for the schema: its title, the
+// component it refers to, or else its location within the spec.
+func schemaLabel(s *jsonschema.Schema) string {
+	if s.Title != "" {
+		return s.Title
+	}
+	if s.Ref != nil {
+		return schemaLabel(s.Ref)
+	}
+	return siteLocation(s.Location)
+}
+
+// siteLocation shortens the absolute location of a schema to its JSON pointer
+// within the spec, relative to the component schemas if possible.
+func siteLocation(location string) string {
+	_, ptr, _ := strings.Cut(location, "#")
+	if rel, ok := strings.CutPrefix(ptr, "/components/schemas/"); ok {
+		return rel
+	}
+	return ptr
+}
+
+// uncovered is a part of a method's schema not exercised by any fixture.
+type uncovered struct {
+	Method   string `json:"method"`
+	Location string `json:"location"`
+	Site     string `json:"site,omitempty"`
+	Schema   string `json:"schema,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	Message  string `json:"message"`
+}
+
+// coverageReport lists the uncovered parts of each method in a stable order.
+type coverageReport struct {
+	Untested  []string     `json:"untested"`
+	Uncovered []*uncovered `json:"uncovered"`
+}
+
+func newCoverageReport(cov map[string]*methodCoverage) *coverageReport {
+	r := &coverageReport{Untested: []string{}, Uncovered: []*uncovered{}}
+	for _, name := range slices.Sorted(maps.Keys(cov)) {
+		mc := cov[name]
+		if mc.fixtures == 0 {
+			r.Untested = append(r.Untested, name)
+			continue
+		}
+		for i, cd := range mc.method.params {
+			location := fmt.Sprintf("params[%d]", i)
+			if !mc.sent[i] && !cd.required {
+				r.Uncovered = append(r.Uncovered, &uncovered{
+					Method:   name,
+					Location: location,
+					Message:  fmt.Sprintf("optional parameter %s never sent", cd.name),
+				})
+			}
+			r.addSites(name, location, mc.params[i])
+		}
+		r.addSites(name, "result", mc.result)
+	}
+	return r
+}
+
+func (r *coverageReport) addSites(method, location string, dc *descriptorCoverage) {
+	for _, key := range slices.Sorted(maps.Keys(dc.sites)) {
+		site := dc.sites[key]
+		for i, matched := range site.matched {
+			if matched {
+				continue
+			}
+			r.Uncovered = append(r.Uncovered, &uncovered{
+				Method:   method,
+				Location: location,
+				Site:     siteLocation(site.location),
+				Schema:   site.label,
+				Branch:   schemaLabel(site.branches[i]),
+				Message:  fmt.Sprintf("%s branch %d never matched", site.keyword, i),
+			})
+		}
+	}
+}
+
+// writeText writes the coverage report grouped by method.
+func (r *coverageReport) writeText(w io.Writer) {
+	if len(r.Untested) > 0 {
+		fmt.Fprintln(w, "methods without fixtures:")
+		for _, name := range r.Untested {
+			fmt.Fprintf(w, "  %s\n", name)
+		}
+		fmt.Fprintln(w)
+	}
+	var method string
+	for _, u := range r.Uncovered {
+		if u.Method != method {
+			fmt.Fprintf(w, "%s\n", u.Method)
+			method = u.Method
+		}
+		if u.Branch != "" {
+			fmt.Fprintf(w, "  %s: %s [%s]: %s: %s\n", u.Location, u.Schema, u.Site, u.Message, u.Branch)
+		} else {
+			fmt.Fprintf(w, "  %s: %s\n", u.Location, u.Message)
+		}
+	}
+}
+
+// writeJSON writes the coverage report as a JSON object.
+func (r *coverageReport) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
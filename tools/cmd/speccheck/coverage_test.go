@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+const coverageSpec = `{
+  "openrpc": "1.2.4",
+  "info": {"title": "test", "version": "1.0.0"},
+  "methods": [
+    {
+      "name": "eth_test",
+      "params": [
+        {"name": "block", "required": true, "schema": {"type": "string"}},
+        {"name": "full", "schema": {"type": "boolean"}}
+      ],
+      "result": {"name": "result", "schema": {
+        "oneOf": [
+          {"title": "not found", "type": "null"},
+          {"$ref": "#/components/schemas/Block"}
+        ]
+      }}
+    },
+    {
+      "name": "eth_untested",
+      "params": [],
+      "result": {"name": "result", "schema": {"type": "string"}}
+    }
+  ],
+  "components": {
+    "schemas": {
+      "Block": {
+        "title": "Block",
+        "type": "object",
+        "properties": {
+          "transactions": {
+            "type": "array",
+            "items": {
+              "anyOf": [
+                {"title": "hash", "type": "string"},
+                {"title": "transaction", "type": "object"}
+              ]
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestCoverage(t *testing.T) {
+	methods := readTestSpec(t, coverageSpec)
+	file := writeTestFile(t, `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0x1"]}
+<< {"jsonrpc":"2.0","id":1,"result":{"transactions":["0xaa"]}}
+>> {"jsonrpc":"2.0","id":2,"method":"eth_test","params":["0x2"]}
+<< {"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"invalid"}}`)
+	rts, err := readTest("test", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newCoverageReport(coverage(methods, rts))
+
+	if len(r.Untested) != 1 || r.Untested[0] != "eth_untested" {
+		t.Errorf("untested = %v, want [eth_untested]", r.Untested)
+	}
+	// Branch sites are reported in order of their schema location.
+	type entry struct{ Location, Branch, Message string }
+	want := []entry{
+		{"params[1]", "", "optional parameter full never sent"},
+		{"result", "transaction", "anyOf branch 1 never matched"},
+		{"result", "not found", "oneOf branch 0 never matched"},
+	}
+	var got []entry
+	for _, u := range r.Uncovered {
+		if u.Method != "eth_test" {
+			t.Errorf("uncovered entry of method %s", u.Method)
+		}
+		got = append(got, entry{u.Location, u.Branch, u.Message})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d uncovered entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCoverageFullyCovered(t *testing.T) {
+	methods := readTestSpec(t, coverageSpec)
+	delete(methods, "eth_untested")
+	file := writeTestFile(t, `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0x1",true]}
+<< {"jsonrpc":"2.0","id":1,"result":{"transactions":[{"hash":"0xaa"}, "0xbb"]}}
+>> {"jsonrpc":"2.0","id":2,"method":"eth_test","params":["0x2"]}
+<< {"jsonrpc":"2.0","id":2,"result":null}`)
+	rts, err := readTest("test", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newCoverageReport(coverage(methods, rts))
+	if len(r.Untested) != 0 || len(r.Uncovered) != 0 {
+		t.Errorf("unexpected report: untested %v, uncovered %d", r.Untested, len(r.Uncovered))
+	}
+	var buf bytes.Buffer
+	r.writeText(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestCoverageWriteText(t *testing.T) {
+	r := &coverageReport{
+		Untested: []string{"eth_b"},
+		Uncovered: []*uncovered{
+			{Method: "eth_a", Location: "params[1]", Message: "optional parameter full never sent"},
+			{Method: "eth_a", Location: "result", Site: "Block/properties/transactions/items", Schema: "Block", Branch: "transaction", Message: "anyOf branch 1 never matched"},
+		},
+	}
+	var buf bytes.Buffer
+	r.writeText(&buf)
+	want := `methods without fixtures:
+  eth_b
+
+eth_a
+  params[1]: optional parameter full never sent
+  result: Block [Block/properties/transactions/items]: anyOf branch 1 never matched: transaction
+`
+	if buf.String() != want {
+		t.Errorf("wrong output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// testFilter selects the fixtures to check. Fixtures live in one directory per
+// method under the tests root, so a fixture is identified by its method and
+// test name, e.g. "eth_getBlockByNumber/get-genesis".
+type testFilter struct {
+	re      *regexp.Regexp
+	include []string // globs matching the method name
+	exclude []string // globs matching the method name
+	tests   []string // globs matching the test name, or "<method>/<test>"
+}
+
+// newTestFilter creates a filter, checking that all patterns are well-formed.
+func newTestFilter(re *regexp.Regexp, include, exclude, tests []string) (*testFilter, error) {
+	for _, pattern := range slices.Concat(include, exclude, tests) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %v", pattern, err)
+		}
+	}
+	return &testFilter{re: re, include: include, exclude: exclude, tests: tests}, nil
+}
+
+// match reports whether the fixture at the given path relative to the tests
+// root, without the .io suffix, should be checked.
+func (f *testFilter) match(name string) bool {
+	if !f.re.MatchString("/" + name) {
+		return false
+	}
+	method, test, ok := strings.Cut(name, "/")
+	if !ok {
+		method, test = "", name
+	}
+	if len(f.include) > 0 && !matchAny(f.include, method) {
+		return false
+	}
+	if matchAny(f.exclude, method) {
+		return false
+	}
+	if len(f.tests) == 0 {
+		return true
+	}
+	for _, pattern := range f.tests {
+		target := test
+		if strings.Contains(pattern, "/") {
+			target = name
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny reports whether name matches any of the glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
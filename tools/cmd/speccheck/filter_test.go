@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestTestFilter(t *testing.T) {
+	all := regexp.MustCompile(".*")
+	tests := []struct {
+		name             string
+		re               *regexp.Regexp
+		include, exclude []string
+		tests            []string
+		match            []string
+		skip             []string
+	}{
+		{
+			name:  "no filter",
+			re:    all,
+			match: []string{"eth_call/call-simple", "debug_getRawBlock/get-genesis"},
+		},
+		{
+			name:  "regexp",
+			re:    regexp.MustCompile("^/eth_call/"),
+			match: []string{"eth_call/call-simple"},
+			skip:  []string{"eth_callMany/call-simple", "debug_getRawBlock/get-genesis"},
+		},
+		{
+			name:    "include method",
+			re:      all,
+			include: []string{"debug_*"},
+			match:   []string{"debug_getRawBlock/get-genesis"},
+			skip:    []string{"eth_call/call-simple"},
+		},
+		{
+			name:    "exclude method",
+			re:      all,
+			exclude: []string{"eth_call"},
+			match:   []string{"eth_callMany/call-simple", "debug_getRawBlock/get-genesis"},
+			skip:    []string{"eth_call/call-simple"},
+		},
+		{
+			name:    "exclude wins over include",
+			re:      all,
+			include: []string{"eth_*"},
+			exclude: []string{"eth_call"},
+			match:   []string{"eth_callMany/call-simple"},
+			skip:    []string{"eth_call/call-simple", "debug_getRawBlock/get-genesis"},
+		},
+		{
+			name:  "test name",
+			re:    all,
+			tests: []string{"*-genesis"},
+			match: []string{"debug_getRawBlock/get-genesis", "eth_getBlockByNumber/get-genesis"},
+			skip:  []string{"eth_call/call-simple"},
+		},
+		{
+			name:  "method and test name",
+			re:    all,
+			tests: []string{"eth_*/get-genesis"},
+			match: []string{"eth_getBlockByNumber/get-genesis"},
+			skip:  []string{"debug_getRawBlock/get-genesis"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newTestFilter(tt.re, tt.include, tt.exclude, tt.tests)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.match {
+				if !f.match(name) {
+					t.Errorf("%s not matched", name)
+				}
+			}
+			for _, name := range tt.skip {
+				if f.match(name) {
+					t.Errorf("%s matched", name)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTestFilterInvalidGlob(t *testing.T) {
+	if _, err := newTestFilter(regexp.MustCompile(".*"), nil, nil, []string{"eth_[call"}); err == nil {
+		t.Error("no error for malformed glob")
+	}
+}
+
+func TestReadRttsFilter(t *testing.T) {
+	root := t.TempDir()
+	const fixture = `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0x1"]}
+<< {"jsonrpc":"2.0","id":1,"result":"0x1"}
+>> {"jsonrpc":"2.0","id":2,"method":"eth_test","params":["0x2"]}
+<< {"jsonrpc":"2.0","id":2,"result":"0x2"}`
+	for _, name := range []string{"eth_test/a.io", "eth_test/b.io", "eth_other/a.io"} {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(fixture), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := newTestFilter(regexp.MustCompile(".*"), []string{"eth_test"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rts, err := readRtts(root, f, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rts) != 4 {
+		t.Fatalf("got %d round trips, want 4", len(rts))
+	}
+
+	var buf bytes.Buffer
+	listTests(&buf, rts)
+	want := filepath.Join(root, "eth_test", "a.io") + "\n" + filepath.Join(root, "eth_test", "b.io") + "\n"
+	if buf.String() != want {
+		t.Errorf("wrong list:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 
@@ -9,39 +11,115 @@ import (
 )
 
 type Args struct {
-	SpecPath   string `arg:"--spec" help:"path to client binary" default:"openrpc.json"`
-	TestsRoot  string `arg:"--tests" help:"path to tests directory" default:"tests"`
-	TestsRegex string `arg:"--regexp" help:"regular expression to match tests to check" deafult:".*"`
-	Verbose    bool   `arg:"-v,--verbose" help:"verbosity level of rpctestgen"`
+	SpecPath       string   `arg:"--spec" help:"path to client binary" default:"openrpc.json"`
+	SpecBase       string   `arg:"--spec-base" help:"path to a previous spec; report only failures which don't occur against it"`
+	TestsRoot      string   `arg:"--tests" help:"path to tests directory" default:"tests"`
+	TestsRegex     string   `arg:"--regexp" help:"regular expression to match tests to check" default:".*"`
+	IncludeMethods []string `arg:"--include-method,separate" help:"only check methods matching the glob, e.g. debug_*"`
+	ExcludeMethods []string `arg:"--exclude-method,separate" help:"skip methods matching the glob"`
+	Tests          []string `arg:"--test,separate" help:"only check tests matching the glob, either by test name or as <method>/<test>"`
+	List           bool     `arg:"--list" help:"list the tests which would be checked without checking them"`
+	IgnoreMessages bool     `arg:"--ignore-error-messages" help:"don't require error messages to match the declared message"`
+	Format         string   `arg:"--format" help:"output format (text or json)" default:"text"`
+	Strict         bool     `arg:"--strict" help:"fail on result fields which are not declared by the schema"`
+	Coverage       bool     `arg:"--coverage" help:"report which parts of the method schemas are not exercised by the tests"`
+	Verbose        bool     `arg:"-v,--verbose" help:"verbosity level of rpctestgen"`
 }
 
 func main() {
 	var args Args
 	arg.MustParse(&args)
-	if err := run(&args); err != nil {
-		exit(err)
+	err := run(&args)
+	if errors.Is(err, errFailed) {
+		os.Exit(1)
 	}
+	exit(err)
 }
 
+// errFailed is returned by run if checks failed and the failures were already
+// reported in the output, including the summary.
+var errFailed = errors.New("checks failed")
+
 func run(args *Args) error {
 	re, err := regexp.Compile(args.TestsRegex)
 	if err != nil {
 		return err
 	}
-
-	// Read all method schemas (params+result) from the OpenRPC spec.
-	methods, err := parseSpec(args.SpecPath)
+	if args.Format != "text" && args.Format != "json" {
+		return fmt.Errorf("unknown output format: %s", args.Format)
+	}
+	if args.SpecBase != "" && args.Coverage {
+		return fmt.Errorf("--spec-base can't be used with --coverage")
+	}
+	filter, err := newTestFilter(re, args.IncludeMethods, args.ExcludeMethods, args.Tests)
 	if err != nil {
 		return err
 	}
 
 	// Read all tests and parse out roundtrip HTTP exchanges so they can be validated.
-	rts, err := readRtts(args.TestsRoot, re)
+	rts, err := readRtts(args.TestsRoot, filter, args.Verbose)
 	if err != nil {
 		return err
 	}
+	if len(rts) == 0 {
+		return fmt.Errorf("no tests selected in %s", args.TestsRoot)
+	}
+	if args.List {
+		listTests(os.Stdout, rts)
+		return nil
+	}
 
-	return checkSpec(methods, rts, re)
+	// Read all method schemas (params+result) from the OpenRPC spec.
+	methods, err := parseSpec(args.SpecPath)
+	if err != nil {
+		return err
+	}
+
+	if args.Coverage {
+		r := newCoverageReport(coverage(methods, rts))
+		if args.Format == "json" {
+			return r.writeJSON(os.Stdout)
+		}
+		r.writeText(os.Stdout)
+		return nil
+	}
+
+	opts := checkOptions{ignoreMessages: args.IgnoreMessages, strict: args.Strict}
+	r := checkSpec(methods, rts, opts)
+	if args.SpecBase != "" {
+		// Only tests broken by the changes since the base spec are reported.
+		baseMethods, err := parseSpec(args.SpecBase)
+		if err != nil {
+			return fmt.Errorf("base spec: %v", err)
+		}
+		r = r.regressions(checkSpec(baseMethods, rts, opts))
+		if args.Format == "text" && len(r.failures) == 0 {
+			fmt.Printf("no breaking changes since %s.\n", args.SpecBase)
+			return nil
+		}
+	}
+	if args.Format == "json" {
+		if err := r.writeJSON(os.Stdout); err != nil {
+			return err
+		}
+		return r.err()
+	}
+	r.writeText(os.Stdout)
+	if len(r.failures) > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+// listTests prints the file of each test, one per line.
+func listTests(w io.Writer, rts []*roundTrip) {
+	var last string
+	for _, rt := range rts {
+		if rt.file != last {
+			fmt.Fprintln(w, rt.file)
+			last = rt.file
+		}
+	}
 }
 
 func exit(err error) {
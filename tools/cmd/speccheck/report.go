@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// failure is a single problem found while checking a round trip.
+type failure struct {
+	Method   string `json:"method"`
+	Test     string `json:"test"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Location string `json:"location"`
+	Pointer  string `json:"pointer"`
+	Schema   string `json:"schema,omitempty"`
+	Message  string `json:"message"`
+}
+
+// report collects the failures of a full speccheck run.
+type report struct {
+	checked  int
+	failures []*failure
+
+	// undeclared holds the paths of undeclared result fields found in strict
+	// mode, by method.
+	undeclared map[string]map[string]bool
+}
+
+func (r *report) fail(rt *roundTrip, location, pointer, schema, msg string) {
+	method := rt.method
+	if rt.request.invalid {
+		method = "(invalid request)"
+	}
+	r.failures = append(r.failures, &failure{
+		Method:   method,
+		Test:     path.Base(rt.name),
+		File:     rt.file,
+		Line:     rt.line,
+		Location: location,
+		Pointer:  pointer,
+		Schema:   schema,
+		Message:  msg,
+	})
+}
+
+// add records err as a failure of the round trip at the given location, e.g.
+// "params[0]" or "result". Schema validation errors are expanded into one
+// failure per failing field. A nil err is ignored.
+func (r *report) add(rt *roundTrip, location string, err error) {
+	if err == nil {
+		return
+	}
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		r.fail(rt, location, "", "", err.Error())
+		return
+	}
+	seen := make(map[string]bool)
+	for _, leaf := range leafErrors(ve) {
+		key := leaf.InstanceLocation + "\x00" + leaf.Message
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		schema := schemaComponent(leaf.AbsoluteKeywordLocation)
+		r.fail(rt, location, leaf.InstanceLocation, schema, leaf.Message)
+	}
+}
+
+// addUndeclared records a failure for each field of the round trip's value at
+// the given location which is not declared by the schema.
+func (r *report) addUndeclared(rt *roundTrip, location string, fields []undeclaredField) {
+	for _, f := range fields {
+		r.fail(rt, location, f.pointer, "", "field not declared in schema")
+		r.markUndeclared(rt.method, location+" "+f.path)
+	}
+}
+
+// markUndeclared records an undeclared field path of the method.
+func (r *report) markUndeclared(method, path string) {
+	if r.undeclared == nil {
+		r.undeclared = make(map[string]map[string]bool)
+	}
+	if r.undeclared[method] == nil {
+		r.undeclared[method] = make(map[string]bool)
+	}
+	r.undeclared[method][path] = true
+}
+
+// undeclaredPaths returns the sorted undeclared field paths of each method.
+func (r *report) undeclaredPaths() map[string][]string {
+	paths := make(map[string][]string, len(r.undeclared))
+	for method, set := range r.undeclared {
+		paths[method] = slices.Sorted(maps.Keys(set))
+	}
+	return paths
+}
+
+// leafErrors returns the innermost errors of the validation error tree. These
+// carry the JSON pointer of the field which failed validation.
+func leafErrors(ve *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(ve.Causes) == 0 {
+		return []*jsonschema.ValidationError{ve}
+	}
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range ve.Causes {
+		leaves = append(leaves, leafErrors(cause)...)
+	}
+	return leaves
+}
+
+// regressions returns a report of the failures which don't occur in the base
+// report, i.e. the failures caused by the changes from the base spec. Failures
+// are the same if they occur at the same line and location, with the same
+// message. A new failure of a test which already failed is a regression too.
+func (r *report) regressions(base *report) *report {
+	known := make(map[failure]bool)
+	for _, f := range base.failures {
+		known[f.key()] = true
+	}
+	out := &report{checked: r.checked}
+	for _, f := range r.failures {
+		if !known[f.key()] {
+			out.failures = append(out.failures, f)
+		}
+	}
+	for method, paths := range r.undeclared {
+		for p := range paths {
+			if !base.undeclared[method][p] {
+				out.markUndeclared(method, p)
+			}
+		}
+	}
+	return out
+}
+
+// key returns the failure without the schema, which may differ between specs
+// for the same failure.
+func (f *failure) key() failure {
+	k := *f
+	k.Schema = ""
+	return k
+}
+
+// failedTests returns the number of distinct tests with at least one failure.
+func (r *report) failedTests() int {
+	tests := make(map[string]bool)
+	for _, f := range r.failures {
+		tests[f.File] = true
+	}
+	return len(tests)
+}
+
+// err returns an error summarizing the run, or nil if all checks passed.
+func (r *report) err() error {
+	if len(r.failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d failures in %d tests", len(r.failures), r.failedTests())
+}
+
+// writeText writes the failures grouped by method and test.
+func (r *report) writeText(w io.Writer) {
+	if len(r.failures) == 0 {
+		fmt.Fprintln(w, "all passing.")
+		return
+	}
+	// Failures are recorded in the order of the round trips, so a batch
+	// interleaves the failures of its methods. Group them by method, keeping
+	// the order of the tests within each method.
+	failures := slices.Clone(r.failures)
+	slices.SortStableFunc(failures, func(a, b *failure) int {
+		return strings.Compare(a.Method, b.Method)
+	})
+	var method, file string
+	for _, f := range failures {
+		if f.Method != method {
+			fmt.Fprintf(w, "%s\n", f.Method)
+			method, file = f.Method, ""
+		}
+		if f.File != file {
+			fmt.Fprintf(w, "  %s (%s:%d)\n", f.Test, f.File, f.Line)
+			file = f.File
+		}
+		where := f.Location
+		if f.Pointer != "" {
+			where += " at " + f.Pointer
+		}
+		if f.Schema != "" {
+			where += " (" + f.Schema + ")"
+		}
+		fmt.Fprintf(w, "    %s: %s\n", where, f.Message)
+	}
+	if len(r.undeclared) > 0 {
+		fmt.Fprintln(w, "\nundeclared fields:")
+		paths := r.undeclaredPaths()
+		for _, method := range slices.Sorted(maps.Keys(paths)) {
+			fmt.Fprintf(w, "  %s\n", method)
+			for _, p := range paths[method] {
+				fmt.Fprintf(w, "    %s\n", p)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d failures in %d tests (%d round trips checked)\n", len(r.failures), r.failedTests(), r.checked)
+}
+
+// writeJSON writes the report as a JSON object for consumption by CI.
+func (r *report) writeJSON(w io.Writer) error {
+	out := struct {
+		Checked    int                 `json:"checked"`
+		Failures   []*failure          `json:"failures"`
+		Undeclared map[string][]string `json:"undeclared,omitempty"`
+	}{r.checked, r.failures, r.undeclaredPaths()}
+	if out.Failures == nil {
+		out.Failures = []*failure{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
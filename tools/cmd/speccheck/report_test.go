@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCheckSpecCollectsFailures(t *testing.T) {
+	methods := readTestSpec(t, testSpec)
+	file := writeTestFile(t, `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0xzz"]}
+<< {"jsonrpc":"2.0","id":1,"result":1}
+>> {"jsonrpc":"2.0","id":2,"method":"eth_unknown","params":[]}
+<< {"jsonrpc":"2.0","id":2,"result":"0x1"}
+>> {"jsonrpc":"2.0","id":3,"method":"eth_test","params":[]}
+<< {"jsonrpc":"2.0","id":3,"error":{"code":5,"message":"boom"}}`)
+	rts, err := readTest("test", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := checkSpec(methods, rts, checkOptions{})
+
+	type result struct {
+		Method, Location, Pointer string
+		Line                      int
+	}
+	want := []result{
+		{"eth_test", "params[0]", "", 1},
+		{"eth_test", "result", "", 1},
+		{"eth_unknown", "method", "", 3},
+		{"eth_test", "params[0]", "", 5},
+		{"eth_test", "error", "", 5},
+	}
+	var got []result
+	for _, f := range r.failures {
+		got = append(got, result{f.Method, f.Location, f.Pointer, f.Line})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d failures, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("failure %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if r.checked != 3 {
+		t.Errorf("checked = %d, want 3", r.checked)
+	}
+	if r.failedTests() != 1 {
+		t.Errorf("failed tests = %d, want 1", r.failedTests())
+	}
+}
+
+func TestReportPointers(t *testing.T) {
+	spec := `{
+  "openrpc": "1.2.4",
+  "info": {"title": "test", "version": "1.0.0"},
+  "methods": [{
+    "name": "eth_test",
+    "params": [],
+    "result": {"name": "result", "schema": {
+      "type": "object",
+      "properties": {"a": {"type": "string"}, "b": {"type": "array", "items": {"type": "string"}}}
+    }}
+  }]
+}`
+	methods := readTestSpec(t, spec)
+	file := writeTestFile(t, `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":[]}
+<< {"jsonrpc":"2.0","id":1,"result":{"a":1,"b":["x",2]}}`)
+	rts, err := readTest("test", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := checkSpec(methods, rts, checkOptions{})
+
+	pointers := make(map[string]bool)
+	for _, f := range r.failures {
+		pointers[f.Pointer] = true
+	}
+	for _, p := range []string{"/a", "/b/1"} {
+		if !pointers[p] {
+			t.Errorf("no failure at %s", p)
+		}
+	}
+	if len(r.failures) != 2 {
+		t.Errorf("got %d failures, want 2", len(r.failures))
+	}
+}
+
+func TestReportWriteText(t *testing.T) {
+	r := &report{
+		checked: 4,
+		failures: []*failure{
+			{Method: "eth_a", Test: "t1", File: "eth_a/t1.io", Line: 2, Location: "result", Pointer: "/x", Message: "expected string"},
+			{Method: "eth_a", Test: "t1", File: "eth_a/t1.io", Line: 2, Location: "params[0]", Message: "missing"},
+			{Method: "eth_b", Test: "t2", File: "eth_b/t2.io", Line: 4, Location: "error", Message: "undeclared"},
+		},
+	}
+	var buf bytes.Buffer
+	r.writeText(&buf)
+	want := `eth_a
+  t1 (eth_a/t1.io:2)
+    result at /x: expected string
+    params[0]: missing
+eth_b
+  t2 (eth_b/t2.io:4)
+    error: undeclared
+
+3 failures in 2 tests (4 round trips checked)
+`
+	if buf.String() != want {
+		t.Errorf("wrong output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if err := r.err(); err == nil || err.Error() != "3 failures in 2 tests" {
+		t.Errorf("err = %v", err)
+	}
+
+	buf.Reset()
+	new(report).writeText(&buf)
+	if buf.String() != "all passing.\n" {
+		t.Errorf("wrong output for empty report: %q", buf.String())
+	}
+}
+
+func TestReportWriteTextBatch(t *testing.T) {
+	// The failures of a batch interleave its methods.
+	r := &report{
+		checked: 3,
+		failures: []*failure{
+			{Method: "eth_a", Test: "batch", File: "batch.io", Line: 1, Location: "result", Message: "first"},
+			{Method: "eth_b", Test: "batch", File: "batch.io", Line: 1, Location: "result", Message: "second"},
+			{Method: "eth_a", Test: "batch", File: "batch.io", Line: 1, Location: "params[0]", Message: "third"},
+		},
+	}
+	var buf bytes.Buffer
+	r.writeText(&buf)
+	want := `eth_a
+  batch (batch.io:1)
+    result: first
+    params[0]: third
+eth_b
+  batch (batch.io:1)
+    result: second
+
+3 failures in 1 tests (3 round trips checked)
+`
+	if buf.String() != want {
+		t.Errorf("wrong output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if r.failures[1].Method != "eth_b" {
+		t.Error("writeText reordered the failures of the report")
+	}
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := new(report).writeJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Checked  int               `json:"checked"`
+		Failures []json.RawMessage `json:"failures"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Failures == nil {
+		t.Errorf("failures is null, want []")
+	}
+}
+
+func TestRegressions(t *testing.T) {
+	fail := func(file string, line int, pointer, msg string) *failure {
+		return &failure{Method: "eth_call", Test: file, File: file, Line: line, Location: "result", Pointer: pointer, Message: msg}
+	}
+	base := &report{
+		failures: []*failure{
+			fail("a.io", 2, "/x", "old failure"),
+		},
+		undeclared: map[string]map[string]bool{
+			"eth_call": {"result /x": true},
+		},
+	}
+	current := &report{
+		checked: 3,
+		failures: []*failure{
+			fail("a.io", 2, "/x", "old failure"),
+			fail("a.io", 2, "/y", "new failure in failing test"),
+			fail("a.io", 4, "/x", "old failure"),
+			fail("b.io", 2, "/x", "new failure"),
+		},
+		undeclared: map[string]map[string]bool{
+			"eth_call": {"result /x": true, "result /z": true},
+		},
+	}
+	r := current.regressions(base)
+
+	want := current.failures[1:]
+	if !reflect.DeepEqual(r.failures, want) {
+		t.Errorf("wrong regressions:")
+		for _, f := range r.failures {
+			t.Logf("  %+v", f)
+		}
+	}
+	wantUndeclared := map[string][]string{"eth_call": {"result /z"}}
+	if got := r.undeclaredPaths(); !reflect.DeepEqual(got, wantUndeclared) {
+		t.Errorf("undeclared = %v, want %v", got, wantUndeclared)
+	}
+	if r.checked != 3 {
+		t.Errorf("checked = %d, want 3", r.checked)
+	}
+}
@@ -1,21 +1,47 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 )
 
+// jsonrpcMessage is a JSON-RPC request or response. The raw members are nil if
+// absent from the message, and hold "null" if the member is present but null.
 type jsonrpcMessage struct {
-	Version string          `json:"jsonrpc,omitempty"`
+	Version json.RawMessage `json:"jsonrpc,omitempty"`
 	ID      json.RawMessage `json:"id,omitempty"`
 	Method  string          `json:"method,omitempty"`
 	Params  json.RawMessage `json:"params,omitempty"`
 	Error   *jsonError      `json:"error,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
+
+	hasError bool // whether the error member is present, even if null
+
+	// invalid is set if the message is not an object, e.g. null or a number in
+	// a batch. raw holds the message as found in the test.
+	invalid bool
+	raw     json.RawMessage
+}
+
+// UnmarshalJSON decodes the message, recording whether the error member is
+// present.
+func (msg *jsonrpcMessage) UnmarshalJSON(data []byte) error {
+	type message jsonrpcMessage
+	var members struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*message)(msg)); err != nil {
+		return err
+	}
+	msg.hasError = members.Error != nil
+	return nil
 }
 
 type jsonError struct {
@@ -25,17 +51,36 @@ type jsonError struct {
 }
 
 // roundTrip is a single round trip interaction between a certain JSON-RPC
-// method.
+// method. Requests sent in a batch are split into one round trip each, paired
+// with their response by id.
 type roundTrip struct {
 	method   string
 	name     string
+	file     string
+	line     int
 	params   [][]byte
-	response *jsonrpcMessage
+	request  *jsonrpcMessage
+	response *jsonrpcMessage // nil if no response was received
+
+	// validParams is set by a "validparams:" comment in the test file. It
+	// marks a test with "invalid" in its name whose requests are well-formed
+	// and fail for reasons the spec doesn't express.
+	validParams bool
+
+	// exchangeErrs are problems found while pairing the request with its
+	// response, e.g. responses with unknown or duplicate ids within a batch.
+	exchangeErrs []error
+}
+
+// isNotification reports whether the request is a notification, i.e. the
+// client doesn't expect a response.
+func (rt *roundTrip) isNotification() bool {
+	return !rt.request.invalid && len(rt.request.ID) == 0
 }
 
 // readRtts walks a root directory and parses round trip HTTP exchanges
-// from files that match the regular expression.
-func readRtts(root string, re *regexp.Regexp) ([]*roundTrip, error) {
+// from files selected by the filter.
+func readRtts(root string, filter *testFilter, verbose bool) ([]*roundTrip, error) {
 	rts := make([]*roundTrip, 0)
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -49,8 +94,14 @@ func readRtts(root string, re *regexp.Regexp) ([]*roundTrip, error) {
 			return nil
 		}
 		pathname := strings.TrimSuffix(strings.TrimPrefix(path, root), ".io")
-		if !re.MatchString(pathname) {
-			fmt.Println("skip", pathname)
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if !filter.match(strings.TrimSuffix(filepath.ToSlash(rel), ".io")) {
+			if verbose {
+				fmt.Fprintln(os.Stderr, "skip", pathname)
+			}
 			return nil // skip
 		}
 		// Found a good test, parse it and append to list.
@@ -68,45 +119,184 @@ func readRtts(root string, re *regexp.Regexp) ([]*roundTrip, error) {
 }
 
 // readTest reads a single test into a slice of HTTP round trips.
+//
+// Each request line ">>" holds either a single request object or a batch of
+// requests, and is followed by a response line "<<" holding the response object
+// or the batch of responses. The response line is omitted if the request is a
+// notification, or a batch of notifications only.
 func readTest(testname string, filename string) ([]*roundTrip, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	rts := make([]*roundTrip, 0)
-	var req *jsonrpcMessage
-	for _, line := range strings.Split(string(data), "\n") {
+	var (
+		rts         = make([]*roundTrip, 0)
+		pending     []*roundTrip
+		batch       bool
+		validParams bool
+	)
+	// flush completes the pending request when no response follows it.
+	flush := func() error {
+		for _, rt := range pending {
+			if !rt.isNotification() {
+				return fmt.Errorf("unhandled request")
+			}
+		}
+		rts = append(rts, pending...)
+		pending = nil
+		return nil
+	}
+	for i, line := range strings.Split(string(data), "\n") {
 		line = strings.TrimSpace(line)
 		switch {
-		case len(line) == 0 || strings.HasPrefix(line, "//"):
-			// Skip comments, blank lines.
+		case len(line) == 0:
+			continue
+		case strings.HasPrefix(line, "//"):
+			// Skip comments, but note annotations meant for speccheck.
+			if strings.HasPrefix(strings.TrimSpace(line[2:]), "validparams:") {
+				validParams = true
+			}
 			continue
 		case strings.HasPrefix(line, ">> "):
-			req = &jsonrpcMessage{}
-			if err := json.Unmarshal([]byte(line[3:]), &req); err != nil {
+			if err := flush(); err != nil {
 				return nil, err
 			}
+			var reqs []*jsonrpcMessage
+			reqs, batch, err = parseMessages(line[3:])
+			if err != nil {
+				return nil, err
+			}
+			if len(reqs) == 0 {
+				return nil, fmt.Errorf("empty batch request")
+			}
+			for _, req := range reqs {
+				if req.invalid {
+					// The server must answer with an error, there is no method.
+					pending = append(pending, &roundTrip{name: testname, file: filename, line: i + 1, request: req})
+					continue
+				}
+				// Parse parameters into slice of string.
+				params, err := parseParamValues(req.Params)
+				if err != nil {
+					return nil, fmt.Errorf("unable to parse params: %s %v", err, req.Params)
+				}
+				rt := &roundTrip{method: req.Method, name: testname, file: filename, line: i + 1, params: params, request: req, validParams: validParams}
+				pending = append(pending, rt)
+			}
 		case strings.HasPrefix(line, "<< "):
-			if req == nil {
+			if pending == nil {
 				return nil, fmt.Errorf("response w/o corresponding request")
 			}
-			var resp jsonrpcMessage
-			if err := json.Unmarshal([]byte(line[3:]), &resp); err != nil {
+			resps, respBatch, err := parseMessages(line[3:])
+			if err != nil {
 				return nil, err
 			}
-			// Parse parameters into slice of string.
-			params, err := parseParamValues(req.Params)
-			if err != nil {
-				return nil, fmt.Errorf("unable to parse params: %s %v", err, req.Params)
+			if batch != respBatch {
+				for _, rt := range pending {
+					rt.exchangeErrs = append(rt.exchangeErrs, fmt.Errorf("batch request answered by non-batch response or vice versa"))
+				}
+			} else {
+				matchResponses(pending, resps)
 			}
-			rts = append(rts, &roundTrip{req.Method, testname, params, &resp})
-			req = nil
+			rts = append(rts, pending...)
+			pending = nil
 		default:
 			return nil, fmt.Errorf("invalid line in test: %s", line)
 		}
 	}
-	if req != nil {
-		return nil, fmt.Errorf("unhandled request")
+	if err := flush(); err != nil {
+		return nil, err
 	}
 	return rts, nil
 }
+
+// parseMessages parses a single JSON-RPC message or a batch of them.
+func parseMessages(text string) (msgs []*jsonrpcMessage, batch bool, err error) {
+	var raws []json.RawMessage
+	if strings.HasPrefix(text, "[") {
+		if err := json.Unmarshal([]byte(text), &raws); err != nil {
+			return nil, true, err
+		}
+		batch = true
+	} else {
+		var raw json.RawMessage
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			return nil, false, err
+		}
+		raws = []json.RawMessage{raw}
+	}
+	for _, raw := range raws {
+		msgs = append(msgs, parseMessage(raw))
+	}
+	return msgs, batch, nil
+}
+
+// parseMessage parses a single JSON-RPC message. Values which are not message
+// objects, e.g. null or a number, are valid JSON-RPC input which servers must
+// answer with an error, so they are returned as invalid messages.
+func parseMessage(raw json.RawMessage) *jsonrpcMessage {
+	msg := new(jsonrpcMessage)
+	if !bytes.HasPrefix(raw, []byte("{")) || json.Unmarshal(raw, msg) != nil {
+		return &jsonrpcMessage{invalid: true, raw: raw}
+	}
+	return msg
+}
+
+// matchResponses pairs each request with its response. A single request is
+// paired with the single response. Within a batch, responses may arrive in any
+// order and are paired by id. Invalid requests have no id, they are paired in
+// order with the responses whose id is null. Every request except
+// notifications must receive exactly one response, and every response must
+// belong to a request.
+func matchResponses(rts []*roundTrip, resps []*jsonrpcMessage) {
+	if len(rts) == 1 && len(resps) == 1 {
+		switch {
+		case resps[0].invalid:
+			rts[0].exchangeErrs = append(rts[0].exchangeErrs, fmt.Errorf("invalid response %s", resps[0].raw))
+		case rts[0].isNotification():
+			rts[0].exchangeErrs = append(rts[0].exchangeErrs, fmt.Errorf("unexpected response to notification"))
+		default:
+			rts[0].response = resps[0]
+		}
+		return
+	}
+	for _, resp := range resps {
+		if resp.invalid {
+			rts[0].exchangeErrs = append(rts[0].exchangeErrs, fmt.Errorf("invalid response %s", resp.raw))
+			continue
+		}
+		var match *roundTrip
+		for _, rt := range rts {
+			if !rt.isNotification() && !rt.request.invalid && bytes.Equal(compactJSON(rt.request.ID), compactJSON(resp.ID)) {
+				match = rt
+				break
+			}
+		}
+		if match == nil && bytes.Equal(compactJSON(resp.ID), []byte("null")) {
+			for _, rt := range rts {
+				if rt.request.invalid && rt.response == nil {
+					match = rt
+					break
+				}
+			}
+		}
+		switch {
+		case match == nil:
+			rts[0].exchangeErrs = append(rts[0].exchangeErrs, fmt.Errorf("response with unknown id %s", resp.ID))
+		case match.response != nil:
+			match.exchangeErrs = append(match.exchangeErrs, fmt.Errorf("duplicate response for id %s", resp.ID))
+		default:
+			match.response = resp
+		}
+	}
+}
+
+// compactJSON removes insignificant whitespace from a JSON value so that values
+// can be compared byte-wise.
+func compactJSON(raw json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const invalidRequestResponse = `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}}`
+
+func TestReadTest(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantInvalid  []bool   // per round trip, whether the request is invalid
+		wantResponse []bool   // per round trip, whether a response was paired
+		wantErrs     []string // exchange errors of the first round trip
+	}{
+		{
+			name: "single request",
+			content: `>> {"jsonrpc":"2.0","id":1,"method":"eth_chainId"}
+<< {"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+			wantInvalid:  []bool{false},
+			wantResponse: []bool{true},
+		},
+		{
+			name: "batch of null",
+			content: `>> [null]
+<< [` + invalidRequestResponse + `]`,
+			wantInvalid:  []bool{true},
+			wantResponse: []bool{true},
+		},
+		{
+			name: "batch of numbers",
+			content: `>> [1,2,3]
+<< [` + invalidRequestResponse + `,` + invalidRequestResponse + `,` + invalidRequestResponse + `]`,
+			wantInvalid:  []bool{true, true, true},
+			wantResponse: []bool{true, true, true},
+		},
+		{
+			name: "mixed batch in any order",
+			content: `>> [{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},"x"]
+<< [` + invalidRequestResponse + `,{"jsonrpc":"2.0","id":1,"result":"0x1"}]`,
+			wantInvalid:  []bool{false, true},
+			wantResponse: []bool{true, true},
+		},
+		{
+			name: "invalid request without response",
+			content: `>> [{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},null]
+<< [{"jsonrpc":"2.0","id":1,"result":"0x1"}]`,
+			wantInvalid:  []bool{false, true},
+			wantResponse: []bool{true, false},
+		},
+		{
+			name: "single invalid request",
+			content: `>> 5
+<< ` + invalidRequestResponse,
+			wantInvalid:  []bool{true},
+			wantResponse: []bool{true},
+		},
+		{
+			name: "invalid response in batch",
+			content: `>> [{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}]
+<< [null]`,
+			wantInvalid:  []bool{false},
+			wantResponse: []bool{false},
+			wantErrs:     []string{"invalid response null"},
+		},
+		{
+			name: "invalid single response",
+			content: `>> {"jsonrpc":"2.0","id":1,"method":"eth_chainId"}
+<< null`,
+			wantInvalid:  []bool{false},
+			wantResponse: []bool{false},
+			wantErrs:     []string{"invalid response null"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rts, err := readTest("test", writeTestFile(t, tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rts) != len(tt.wantInvalid) {
+				t.Fatalf("got %d round trips, want %d", len(rts), len(tt.wantInvalid))
+			}
+			for i, rt := range rts {
+				if rt.request.invalid != tt.wantInvalid[i] {
+					t.Errorf("round trip %d: invalid = %v, want %v", i, rt.request.invalid, tt.wantInvalid[i])
+				}
+				if got := rt.response != nil; got != tt.wantResponse[i] {
+					t.Errorf("round trip %d: has response = %v, want %v", i, got, tt.wantResponse[i])
+				}
+			}
+			var errs []string
+			for _, err := range rts[0].exchangeErrs {
+				errs = append(errs, err.Error())
+			}
+			if strings.Join(errs, "\n") != strings.Join(tt.wantErrs, "\n") {
+				t.Errorf("exchange errors = %q, want %q", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestReadTestMalformed(t *testing.T) {
+	for _, content := range []string{
+		`>> [1,`,
+		`>> {"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`,
+		`<< {"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+		`>> []`,
+	} {
+		if _, err := readTest("test", writeTestFile(t, content)); err == nil {
+			t.Errorf("no error for %q", content)
+		}
+	}
+}
+
+func TestCheckInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  string
+	}{
+		{
+			name:     "invalid request error",
+			response: invalidRequestResponse,
+		},
+		{
+			name:     "wrong code",
+			response: `{"jsonrpc":"2.0","id":null,"error":{"code":-32603,"message":"Internal error"}}`,
+			wantErr:  "invalid request answered by error code -32603, want -32600",
+		},
+		{
+			name:     "id not null",
+			response: `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Invalid Request"}}`,
+			wantErr:  "id 1 of response to invalid request, want null",
+		},
+		{
+			name:     "result",
+			response: `{"jsonrpc":"2.0","id":null,"result":"0x1"}`,
+			wantErr:  "invalid request answered by result",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeTestFile(t, ">> [null]\n<< ["+tt.response+"]")
+			rts, err := readTest("test", file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := checkSpec(nil, rts, checkOptions{})
+			var msgs []string
+			for _, f := range r.failures {
+				msgs = append(msgs, f.Message)
+			}
+			if strings.Join(msgs, "\n") != tt.wantErr {
+				t.Errorf("failures = %q, want %q", msgs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,17 +1,29 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/mattn/go-jsonpointer"
 	openrpc "github.com/open-rpc/spec-types/generated/packages/go/v1_4"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 type ContentDescriptor struct {
 	name     string
 	required bool
-	schema   openrpc.JSONSchemaObject
+	schema   *jsonschema.Schema
+}
+
+// errorSchema is an error which a method declares it may return.
+type errorSchema struct {
+	code    int
+	message string
+	data    *jsonschema.Schema
 }
 
 // methodSchema stores all the schemas neccessary to validate a request or
@@ -20,20 +32,58 @@ type methodSchema struct {
 	name   string
 	params []*ContentDescriptor
 	result *ContentDescriptor
+	errors []*errorSchema
+}
+
+// specParser compiles the schemas of an OpenRPC document. The whole document
+// is added to the compiler as a single resource, so schemas are compiled from
+// their location in the document and any $ref into the components is resolved
+// by the compiler itself.
+type specParser struct {
+	doc      *openrpc.OpenrpcDocument
+	raw      map[string]any
+	url      string
+	compiler *jsonschema.Compiler
 }
 
 // parseSpec reads an OpenRPC specification and parses out each
-// method's schemas.
+// method's schemas. Both dereferenced specs and specs using $ref to
+// components are supported.
 func parseSpec(filename string) (map[string]*methodSchema, error) {
-	doc, err := readSpec(filename)
+	raw, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read spec: %v", err)
 	}
+	var doc openrpc.OpenrpcDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("unable to read spec: %v", err)
+	}
+	var rawDoc map[string]any
+	if err := json.Unmarshal(raw, &rawDoc); err != nil {
+		return nil, fmt.Errorf("unable to read spec: %v", err)
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	p := &specParser{
+		doc:      &doc,
+		raw:      rawDoc,
+		url:      "file://" + filepath.ToSlash(abs),
+		compiler: jsonschema.NewCompiler(),
+	}
+	// Force jsonschema to use draft 2019-09. Annotations are extracted to
+	// label schemas in reports.
+	p.compiler.Draft = jsonschema.Draft2019
+	p.compiler.ExtractAnnotations = true
+	if err := p.compiler.AddResource(p.url, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("unable to load spec: %v", err)
+	}
 
 	// Iterate over each method in the OpenRPC spec and pull out the parameter
 	// schema and result schema.
 	parsed := make(map[string]*methodSchema)
-	for _, method := range *doc.Methods {
+	for i, method := range *doc.Methods {
 		if method.ReferenceObject != nil {
 			return nil, fmt.Errorf("reference object not supported, %s", *method.ReferenceObject.Ref)
 		}
@@ -42,18 +92,10 @@ func parseSpec(filename string) (map[string]*methodSchema, error) {
 			ms     = methodSchema{name: string(*method.Name)}
 		)
 		// Add parameter schemas.
-		for i, param := range *method.Params {
-			if err := checkCDOR(param); err != nil {
-				return nil, fmt.Errorf("%s, parameter %d: %v", *method.Name, i, err)
-			}
-			required := false
-			if param.ContentDescriptorObject.Required != nil && *param.ContentDescriptorObject.Required {
-				required = true
-			}
-			cd := &ContentDescriptor{
-				name:     string(*param.ContentDescriptorObject.Name),
-				required: required,
-				schema:   *param.ContentDescriptorObject.Schema.JSONSchemaObject,
+		for j, param := range *method.Params {
+			cd, err := p.contentDescriptor(param, fmt.Sprintf("/methods/%d/params/%d", i, j))
+			if err != nil {
+				return nil, fmt.Errorf("%s, parameter %d: %v", *method.Name, j, err)
 			}
 			ms.params = append(ms.params, cd)
 		}
@@ -66,18 +108,21 @@ func parseSpec(filename string) (map[string]*methodSchema, error) {
 			ContentDescriptorObject: method.Result.ContentDescriptorObject,
 			ReferenceObject:         method.Result.ReferenceObject,
 		}
-		if err := checkCDOR(cdor); err != nil {
+		cd, err := p.contentDescriptor(cdor, fmt.Sprintf("/methods/%d/result", i))
+		if err != nil {
 			return nil, fmt.Errorf("%s: %v", *method.Name, err)
 		}
-		obj := method.Result.ContentDescriptorObject
-		required := false
-		if obj.Required != nil && *obj.Required {
-			required = true
-		}
-		ms.result = &ContentDescriptor{
-			name:     string(*obj.Name),
-			required: required,
-			schema:   *obj.Schema.JSONSchemaObject,
+		ms.result = cd
+
+		// Add declared errors.
+		if method.Errors != nil {
+			for j, eor := range *method.Errors {
+				e, err := p.error(eor, fmt.Sprintf("/methods/%d/errors/%d", i, j))
+				if err != nil {
+					return nil, fmt.Errorf("%s, error %d: %v", *method.Name, j, err)
+				}
+				ms.errors = append(ms.errors, e)
+			}
 		}
 		parsed[string(*method.Name)] = &ms
 	}
@@ -85,6 +130,117 @@ func parseSpec(filename string) (map[string]*methodSchema, error) {
 	return parsed, nil
 }
 
+// contentDescriptor resolves the content descriptor found at the given JSON
+// pointer in the document and compiles its schema.
+func (p *specParser) contentDescriptor(obj openrpc.ContentDescriptorOrReference, ptr string) (*ContentDescriptor, error) {
+	cd := obj.ContentDescriptorObject
+	if ref := p.reference(obj.ReferenceObject, ptr); ref != "" {
+		cd = new(openrpc.ContentDescriptorObject)
+		var components map[string]interface{}
+		if p.doc.Components != nil && p.doc.Components.ContentDescriptors != nil {
+			components = *p.doc.Components.ContentDescriptors
+		}
+		var err error
+		ptr, err = resolveRef(ref, "contentDescriptors", components, cd)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := checkCD(cd); err != nil {
+		return nil, err
+	}
+	schema, err := p.compile(ptr + "/schema")
+	if err != nil {
+		return nil, err
+	}
+	return &ContentDescriptor{
+		name:     string(*cd.Name),
+		required: cd.Required != nil && bool(*cd.Required),
+		schema:   schema,
+	}, nil
+}
+
+// error resolves the error found at the given JSON pointer in the document. The
+// error's data field, if present, is compiled as the schema of the data value.
+func (p *specParser) error(obj openrpc.ErrorOrReference, ptr string) (*errorSchema, error) {
+	eo := obj.ErrorObject
+	if ref := p.reference(obj.ReferenceObject, ptr); ref != "" {
+		eo = new(openrpc.ErrorObject)
+		var components map[string]interface{}
+		if p.doc.Components != nil && p.doc.Components.Errors != nil {
+			components = *p.doc.Components.Errors
+		}
+		var err error
+		ptr, err = resolveRef(ref, "errors", components, eo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if eo == nil {
+		return nil, fmt.Errorf("missing error object")
+	}
+	if eo.Code == nil {
+		return nil, fmt.Errorf("missing code")
+	}
+	e := &errorSchema{code: int(*eo.Code)}
+	if eo.Message != nil {
+		e.message = string(*eo.Message)
+	}
+	if eo.Data != nil {
+		schema, err := p.compile(ptr + "/data")
+		if err != nil {
+			return nil, fmt.Errorf("invalid data schema: %v", err)
+		}
+		e.data = schema
+	}
+	return e, nil
+}
+
+// reference returns the $ref of the object at the given JSON pointer in the
+// document, or the empty string if it is not a reference. The OpenRPC types
+// decode a reference into an empty content descriptor or error object, so the
+// document itself is checked as well.
+func (p *specParser) reference(ref *openrpc.ReferenceObject, ptr string) string {
+	if ref != nil && ref.Ref != nil {
+		return string(*ref.Ref)
+	}
+	v, err := jsonpointer.Get(p.raw, ptr)
+	if err != nil {
+		return ""
+	}
+	obj, _ := v.(map[string]any)
+	s, _ := obj["$ref"].(string)
+	return s
+}
+
+// compile compiles the schema at the given JSON pointer in the document.
+func (p *specParser) compile(ptr string) (*jsonschema.Schema, error) {
+	return p.compiler.Compile(p.url + "#" + ptr)
+}
+
+// resolveRef looks up a reference of the form "#/components/<kind>/<name>" in
+// components and decodes the component into v. It returns the JSON pointer of
+// the component within the document.
+func resolveRef(ref, kind string, components map[string]interface{}, v any) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	name, ok := strings.CutPrefix(ref, prefix)
+	if !ok {
+		return "", fmt.Errorf("unsupported $ref %q: expected prefix %q", ref, prefix)
+	}
+	component, ok := components[name]
+	if !ok {
+		return "", fmt.Errorf("$ref %q not found", ref)
+	}
+	buf, err := json.Marshal(component)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(buf, v); err != nil {
+		return "", fmt.Errorf("$ref %q: %v", ref, err)
+	}
+	return strings.TrimPrefix(ref, "#"), nil
+}
+
 // parseParamValues parses each parameter out of the raw json value in its own byte
 // slice.
 func parseParamValues(raw json.RawMessage) ([][]byte, error) {
@@ -108,31 +264,27 @@ func parseParamValues(raw json.RawMessage) ([][]byte, error) {
 	return out, nil
 }
 
-func checkCDOR(obj openrpc.ContentDescriptorOrReference) error {
-	if obj.ReferenceObject != nil {
-		return fmt.Errorf("references not supported")
-	}
-	if obj.ContentDescriptorObject == nil {
+func checkCD(cd *openrpc.ContentDescriptorObject) error {
+	if cd == nil {
 		return fmt.Errorf("missing content descriptor")
 	}
-	cd := obj.ContentDescriptorObject
 	if cd.Name == nil {
 		return fmt.Errorf("missing name")
 	}
-	if cd.Schema == nil || cd.Schema.JSONSchemaObject == nil {
+	if cd.Schema == nil {
 		return fmt.Errorf("missing schema")
 	}
 	return nil
 }
 
-func readSpec(path string) (*openrpc.OpenrpcDocument, error) {
-	spec, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	var doc openrpc.OpenrpcDocument
-	if err := json.Unmarshal(spec, &doc); err != nil {
-		return nil, err
+// schemaComponent returns the name of the component schema containing the
+// given absolute schema location, or the empty string if the location is not
+// within a component schema.
+func schemaComponent(location string) string {
+	_, ptr, ok := strings.Cut(location, "#/components/schemas/")
+	if !ok {
+		return ""
 	}
-	return &doc, nil
+	name, _, _ := strings.Cut(ptr, "/")
+	return name
 }
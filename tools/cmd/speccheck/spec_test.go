@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+// refsSpec uses references to component schemas, content descriptors and
+// errors, like refs-openrpc.json.
+const refsSpec = `{
+  "openrpc": "1.2.4",
+  "info": {"title": "test", "version": "1.0.0"},
+  "methods": [{
+    "name": "eth_test",
+    "params": [{"$ref": "#/components/contentDescriptors/Block"}],
+    "result": {"name": "result", "schema": {"$ref": "#/components/schemas/uint"}},
+    "errors": [{"$ref": "#/components/errors/Pruned"}]
+  }],
+  "components": {
+    "schemas": {
+      "uint": {"title": "uint", "type": "string", "pattern": "^0x[0-9a-f]+$"}
+    },
+    "contentDescriptors": {
+      "Block": {"name": "block", "required": true, "schema": {"$ref": "#/components/schemas/uint"}}
+    },
+    "errors": {
+      "Pruned": {"code": 4444, "message": "Pruned history unavailable"}
+    }
+  }
+}`
+
+func TestParseSpecRefs(t *testing.T) {
+	method := readTestSpec(t, refsSpec)["eth_test"]
+	if method == nil {
+		t.Fatal("method eth_test missing")
+	}
+	if len(method.params) != 1 || method.params[0].name != "block" || !method.params[0].required {
+		t.Errorf("wrong params: %+v", method.params)
+	}
+	if len(method.errors) != 1 || method.errors[0].code != 4444 {
+		t.Errorf("wrong errors: %+v", method.errors)
+	}
+
+	file := writeTestFile(t, `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":["0x1"]}
+<< {"jsonrpc":"2.0","id":1,"result":"1"}`)
+	rts, err := readTest("test", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := checkSpec(map[string]*methodSchema{"eth_test": method}, rts, checkOptions{})
+	if len(r.failures) != 1 {
+		t.Fatalf("got %d failures, want 1", len(r.failures))
+	}
+	if f := r.failures[0]; f.Location != "result" || f.Schema != "uint" {
+		t.Errorf("failure at %s (%s), want result (uint)", f.Location, f.Schema)
+	}
+}
+
+func TestParseSpecUnresolvableRef(t *testing.T) {
+	spec := `{
+  "openrpc": "1.2.4",
+  "info": {"title": "test", "version": "1.0.0"},
+  "methods": [{
+    "name": "eth_test",
+    "params": [{"$ref": "#/components/contentDescriptors/Missing"}],
+    "result": {"name": "result", "schema": {}}
+  }]
+}`
+	file := writeTestFile(t, spec)
+	if _, err := parseSpec(file); err == nil {
+		t.Error("no error for unresolvable reference")
+	}
+}
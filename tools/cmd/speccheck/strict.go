@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// undeclaredField is an object field in a value which none of the schemas
+// describing the object declares.
+type undeclaredField struct {
+	pointer string // JSON pointer of the field within the value
+	path    string // pointer with array indices replaced by "*"
+}
+
+// undeclaredFields returns the fields of the JSON value which are not declared
+// by the schema. A field is declared if any schema applying to its object lists
+// it in properties, matches it with patternProperties, or allows it through an
+// explicit additionalProperties. Objects whose schemas declare no properties at
+// all are treated as free-form and are not inspected.
+func undeclaredFields(schema *jsonschema.Schema, raw []byte) []undeclaredField {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil
+	}
+	var fields []undeclaredField
+	findUndeclared([]*jsonschema.Schema{schema}, v, "", "", &fields)
+	return fields
+}
+
+func findUndeclared(schemas []*jsonschema.Schema, v interface{}, pointer, path string, fields *[]undeclaredField) {
+	var applied []*jsonschema.Schema
+	for _, s := range schemas {
+		applied = appendApplicable(applied, s, v)
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		freeForm := true
+		for _, s := range applied {
+			if s.Properties != nil || s.PatternProperties != nil || s.AdditionalProperties != nil {
+				freeForm = false
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			var (
+				subs     []*jsonschema.Schema
+				declared bool
+			)
+			for _, s := range applied {
+				if sub, ok := s.Properties[key]; ok {
+					subs = append(subs, sub)
+					declared = true
+					continue
+				}
+				for re, sub := range s.PatternProperties {
+					if re.MatchString(key) {
+						subs = append(subs, sub)
+						declared = true
+					}
+				}
+				switch additional := s.AdditionalProperties.(type) {
+				case *jsonschema.Schema:
+					subs = append(subs, additional)
+					declared = true
+				case bool:
+					declared = declared || additional
+				}
+			}
+			token := escapePointer(key)
+			if !declared && !freeForm {
+				*fields = append(*fields, undeclaredField{pointer + "/" + token, path + "/" + token})
+				continue
+			}
+			findUndeclared(subs, v[key], pointer+"/"+token, path+"/"+token, fields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			var subs []*jsonschema.Schema
+			for _, s := range applied {
+				if sub := itemSchema(s, i); sub != nil {
+					subs = append(subs, sub)
+				}
+			}
+			findUndeclared(subs, item, pointer+"/"+strconv.Itoa(i), path+"/*", fields)
+		}
+	}
+}
+
+// appendApplicable appends s and every schema which applies to the value
+// alongside s: references, allOf, and the oneOf, anyOf and if/then/else branches
+// matched by the value.
+func appendApplicable(applied []*jsonschema.Schema, s *jsonschema.Schema, v interface{}) []*jsonschema.Schema {
+	if s == nil || slices.Contains(applied, s) {
+		return applied
+	}
+	applied = append(applied, s)
+	applied = appendApplicable(applied, s.Ref, v)
+	applied = appendApplicable(applied, s.RecursiveRef, v)
+	for _, sub := range s.AllOf {
+		applied = appendApplicable(applied, sub, v)
+	}
+	for _, branch := range slices.Concat(s.OneOf, s.AnyOf) {
+		if branch.Validate(v) == nil {
+			applied = appendApplicable(applied, branch, v)
+		}
+	}
+	if s.If != nil {
+		if s.If.Validate(v) == nil {
+			applied = appendApplicable(applied, s.Then, v)
+		} else {
+			applied = appendApplicable(applied, s.Else, v)
+		}
+	}
+	return applied
+}
+
+// escapePointer escapes a key for use as a JSON pointer token.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const strictSpec = `{
+  "openrpc": "1.2.4",
+  "info": {"title": "test", "version": "1.0.0"},
+  "methods": [{
+    "name": "eth_test",
+    "params": [],
+    "result": {"name": "result", "schema": {
+      "type": "object",
+      "properties": {
+        "hash": {"type": "string"},
+        "tx": {
+          "oneOf": [
+            {"type": "string"},
+            {"type": "object", "properties": {"from": {"type": "string"}}}
+          ]
+        },
+        "logs": {"type": "array", "items": {"type": "object", "properties": {"address": {"type": "string"}}}},
+        "meta": {"type": "object"},
+        "storage": {"type": "object", "patternProperties": {"^0x": {"type": "string"}}}
+      }
+    }}
+  }]
+}`
+
+func TestUndeclaredFields(t *testing.T) {
+	schema := readTestSpec(t, strictSpec)["eth_test"].result.schema
+	tests := []struct {
+		name  string
+		value string
+		want  []undeclaredField
+	}{
+		{
+			name:  "declared",
+			value: `{"hash":"0x1","tx":{"from":"0x2"},"logs":[{"address":"0x3"}],"storage":{"0x4":"0x5"}}`,
+		},
+		{
+			name:  "top level",
+			value: `{"hash":"0x1","extra":1}`,
+			want:  []undeclaredField{{"/extra", "/extra"}},
+		},
+		{
+			name:  "array items",
+			value: `{"logs":[{"address":"0x3"},{"address":"0x3","data":"0x"}]}`,
+			want:  []undeclaredField{{"/logs/1/data", "/logs/*/data"}},
+		},
+		{
+			name:  "matched oneOf branch",
+			value: `{"tx":{"from":"0x2","to":"0x3"}}`,
+			want:  []undeclaredField{{"/tx/to", "/tx/to"}},
+		},
+		{
+			name:  "free-form object",
+			value: `{"meta":{"anything":1}}`,
+		},
+		{
+			name:  "pattern properties",
+			value: `{"storage":{"0x4":"0x5","key":"0x6"}}`,
+			want:  []undeclaredField{{"/storage/key", "/storage/key"}},
+		},
+		{
+			name:  "escaped key",
+			value: `{"a/b":1}`,
+			want:  []undeclaredField{{"/a~1b", "/a~1b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := undeclaredFields(schema, []byte(tt.value))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("field %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckSpecStrict(t *testing.T) {
+	methods := readTestSpec(t, strictSpec)
+	file := writeTestFile(t, `>> {"jsonrpc":"2.0","id":1,"method":"eth_test","params":[]}
+<< {"jsonrpc":"2.0","id":1,"result":{"logs":[{"address":"0x1","data":"0x"},{"address":"0x2","data":"0x"}]}}`)
+	rts, err := readTest("eth_test/strict", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := checkSpec(methods, rts, checkOptions{}); len(r.failures) != 0 {
+		t.Fatalf("got %d failures without --strict, want 0", len(r.failures))
+	}
+	r := checkSpec(methods, rts, checkOptions{strict: true})
+	if len(r.failures) != 2 {
+		t.Fatalf("got %d failures, want 2", len(r.failures))
+	}
+	for i, f := range r.failures {
+		if f.Location != "result" || f.Message != "field not declared in schema" {
+			t.Errorf("failure %d: %s: %s", i, f.Location, f.Message)
+		}
+	}
+
+	var buf bytes.Buffer
+	r.writeText(&buf)
+	want := "\nundeclared fields:\n  eth_test\n    result /logs/*/data\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output does not list the undeclared path once:\n%s", buf.String())
+	}
+}
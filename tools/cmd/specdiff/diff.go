@@ -0,0 +1,607 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// kind is the category of a change.
+type kind string
+
+const (
+	methodAdded    kind = "method-added"
+	methodRemoved  kind = "method-removed"
+	paramAdded     kind = "param-added"
+	paramRemoved   kind = "param-removed"
+	paramRenamed   kind = "param-renamed"
+	paramRequired  kind = "param-required"
+	schemaWidened  kind = "schema-widened"
+	schemaNarrowed kind = "schema-narrowed"
+	schemaChanged  kind = "schema-changed"
+	keywordChanged kind = "keyword-changed"
+	enumAdded      kind = "enum-added"
+	enumRemoved    kind = "enum-removed"
+	fieldAdded     kind = "field-added"
+	fieldRemoved   kind = "field-removed"
+	fieldRenamed   kind = "field-renamed"
+	errorAdded     kind = "error-added"
+	errorRemoved   kind = "error-removed"
+)
+
+// kinds lists the kinds of change in the order they are reported.
+var kinds = []kind{
+	methodAdded, methodRemoved,
+	paramAdded, paramRemoved, paramRenamed, paramRequired,
+	schemaWidened, schemaNarrowed, schemaChanged, keywordChanged,
+	enumAdded, enumRemoved,
+	fieldAdded, fieldRemoved, fieldRenamed,
+	errorAdded, errorRemoved,
+}
+
+func (k kind) String() string {
+	switch k {
+	case paramRequired:
+		return "param required-ness changed"
+	case keywordChanged:
+		return "schema keyword changed"
+	case enumAdded:
+		return "enum values added"
+	case enumRemoved:
+		return "enum values removed"
+	case errorAdded:
+		return "error codes added"
+	case errorRemoved:
+		return "error codes removed"
+	}
+	return strings.ReplaceAll(string(k), "-", " ")
+}
+
+// direction is the direction in which values of a schema are sent. Whether a
+// change to a schema is breaking depends on it: accepting more values in a
+// request is compatible, returning more values in a response is not.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+// breaking reports whether a change to a schema of the given kind breaks
+// existing users.
+func (dir direction) breaking(k kind) bool {
+	switch k {
+	case schemaWidened, enumAdded:
+		return dir == response
+	case schemaNarrowed, enumRemoved:
+		return dir == request
+	case fieldAdded:
+		return false
+	}
+	return true
+}
+
+// change is a single difference between two specs.
+type change struct {
+	Kind     kind   `json:"kind"`
+	Method   string `json:"method"`
+	Location string `json:"location,omitempty"`
+	Detail   string `json:"detail"`
+	Breaking bool   `json:"breaking"`
+}
+
+func (c change) String() string {
+	s := c.Method
+	if c.Location != "" {
+		s += " " + c.Location
+	}
+	return s + ": " + c.Detail
+}
+
+// differ compares two documents.
+type differ struct {
+	old, new *document
+	changes  []change
+
+	// visiting holds the pairs of references being compared, which stops
+	// recursive schemas from being compared forever.
+	visiting map[[2]string]bool
+}
+
+// diff returns the changes from old to new.
+func diff(old, new *document) []change {
+	d := &differ{old: old, new: new, visiting: make(map[[2]string]bool)}
+	names := slices.Sorted(maps.Keys(old.methods))
+	for name := range new.methods {
+		if _, ok := old.methods[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		o, inOld := old.methods[name]
+		n, inNew := new.methods[name]
+		switch {
+		case !inOld:
+			d.add(change{Kind: methodAdded, Method: name, Detail: "method added"})
+		case !inNew:
+			d.add(change{Kind: methodRemoved, Method: name, Detail: "method removed", Breaking: true})
+		default:
+			d.method(name, o, n)
+		}
+	}
+	return d.changes
+}
+
+func (d *differ) add(c change) {
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) method(name string, o, n object) {
+	// Params are compared by position, since that is how they are sent.
+	oParams, _ := o["params"].([]any)
+	nParams, _ := n["params"].([]any)
+	for i := 0; i < max(len(oParams), len(nParams)); i++ {
+		loc := fmt.Sprintf("params[%d]", i)
+		var op, np object
+		if i < len(oParams) {
+			op, _ = d.old.resolve(oParams[i])
+		}
+		if i < len(nParams) {
+			np, _ = d.new.resolve(nParams[i])
+		}
+		switch {
+		case op == nil && np == nil:
+		case op == nil:
+			d.add(change{Kind: paramAdded, Method: name, Location: loc, Detail: fmt.Sprintf("%s param %q added", requiredness(np), np["name"]), Breaking: isRequired(np)})
+		case np == nil:
+			d.add(change{Kind: paramRemoved, Method: name, Location: loc, Detail: fmt.Sprintf("param %q removed", op["name"]), Breaking: true})
+		default:
+			if op["name"] != np["name"] {
+				d.add(change{Kind: paramRenamed, Method: name, Location: loc, Detail: fmt.Sprintf("param %q renamed to %q", op["name"], np["name"])})
+			}
+			if isRequired(op) != isRequired(np) {
+				d.add(change{Kind: paramRequired, Method: name, Location: loc, Detail: fmt.Sprintf("param %q is now %s", np["name"], requiredness(np)), Breaking: isRequired(np)})
+			}
+			d.schema(name, loc, op["schema"], np["schema"], request)
+		}
+	}
+
+	or, _ := d.old.resolve(o["result"])
+	nr, _ := d.new.resolve(n["result"])
+	d.schema(name, "result", or["schema"], nr["schema"], response)
+
+	oErrors := d.errors(d.old, o)
+	nErrors := d.errors(d.new, n)
+	for _, code := range slices.Sorted(maps.Keys(oErrors)) {
+		ne, ok := nErrors[code]
+		if !ok {
+			d.add(change{Kind: errorRemoved, Method: name, Location: "errors", Detail: fmt.Sprintf("error %d (%v) removed", code, oErrors[code]["message"]), Breaking: true})
+			continue
+		}
+		d.schema(name, fmt.Sprintf("errors[%d].data", code), oErrors[code]["data"], ne["data"], response)
+	}
+	for _, code := range slices.Sorted(maps.Keys(nErrors)) {
+		if _, ok := oErrors[code]; !ok {
+			d.add(change{Kind: errorAdded, Method: name, Location: "errors", Detail: fmt.Sprintf("error %d (%v) added", code, nErrors[code]["message"])})
+		}
+	}
+}
+
+// errors returns the errors declared by a method, by code.
+func (d *differ) errors(doc *document, method object) map[int]object {
+	errs, _ := method["errors"].([]any)
+	byCode := make(map[int]object, len(errs))
+	for _, e := range errs {
+		obj, ok := doc.resolve(e)
+		if !ok {
+			continue
+		}
+		if code, ok := obj["code"].(float64); ok {
+			byCode[int(code)] = obj
+		}
+	}
+	return byCode
+}
+
+func isRequired(param object) bool {
+	required, _ := param["required"].(bool)
+	return required
+}
+
+func requiredness(param object) string {
+	if isRequired(param) {
+		return "required"
+	}
+	return "optional"
+}
+
+// schema compares the old and new schema at loc. A missing schema accepts
+// any value.
+func (d *differ) schema(method, loc string, o, n any, dir direction) {
+	report := func(k kind, format string, args ...any) {
+		d.add(change{Kind: k, Method: method, Location: loc, Detail: fmt.Sprintf(format, args...), Breaking: dir.breaking(k)})
+	}
+
+	// Guard against recursive schemas.
+	oRef, _ := asObject(o)["$ref"].(string)
+	nRef, _ := asObject(n)["$ref"].(string)
+	if oRef != "" && nRef != "" {
+		key := [2]string{oRef, nRef}
+		if d.visiting[key] {
+			return
+		}
+		d.visiting[key] = true
+		defer delete(d.visiting, key)
+	}
+
+	if o == nil {
+		o = object{}
+	}
+	if n == nil {
+		n = object{}
+	}
+	oSchema, oOK := d.old.schema(o)
+	nSchema, nOK := d.new.schema(n)
+	if !oOK || !nOK {
+		if !reflect.DeepEqual(o, n) {
+			report(schemaChanged, "schema changed")
+		}
+		return
+	}
+
+	// Alternatives are matched up and compared with each other. A schema
+	// without alternatives is treated as a single alternative.
+	oKeyword, oAlts := alternatives(oSchema)
+	nKeyword, nAlts := alternatives(nSchema)
+	if oAlts != nil && nAlts != nil && oKeyword != nKeyword {
+		// A value matching several alternatives is rejected by oneOf, but
+		// accepted by anyOf.
+		widened := nKeyword == "anyOf"
+		k := schemaNarrowed
+		if widened {
+			k = schemaWidened
+		}
+		d.add(change{Kind: keywordChanged, Method: method, Location: loc, Detail: fmt.Sprintf("%s changed to %s", oKeyword, nKeyword), Breaking: dir.breaking(k)})
+	}
+	if oAlts != nil || nAlts != nil {
+		if oAlts == nil {
+			oAlts = []any{oSchema}
+		}
+		if nAlts == nil {
+			nAlts = []any{nSchema}
+		}
+		d.alternatives(method, loc, oAlts, nAlts, dir)
+		return
+	}
+
+	// Types.
+	oTypes, nTypes := types(oSchema), types(nSchema)
+	switch {
+	case oTypes == nil && nTypes != nil:
+		report(schemaNarrowed, "type restricted to %s", strings.Join(nTypes, ", "))
+	case oTypes != nil && nTypes == nil:
+		report(schemaWidened, "type no longer restricted")
+	default:
+		for _, t := range nTypes {
+			if !slices.Contains(oTypes, t) {
+				report(schemaWidened, "type %s allowed", t)
+			}
+		}
+		for _, t := range oTypes {
+			if !slices.Contains(nTypes, t) {
+				report(schemaNarrowed, "type %s no longer allowed", t)
+			}
+		}
+	}
+
+	// Enums.
+	oEnum, oHasEnum := oSchema["enum"].([]any)
+	nEnum, nHasEnum := nSchema["enum"].([]any)
+	switch {
+	case !oHasEnum && nHasEnum:
+		report(schemaNarrowed, "restricted to values %s", formatValues(nEnum))
+	case oHasEnum && !nHasEnum:
+		report(schemaWidened, "no longer restricted to values %s", formatValues(oEnum))
+	case oHasEnum && nHasEnum:
+		if added := missing(nEnum, oEnum); len(added) > 0 {
+			report(enumAdded, "values %s added", formatValues(added))
+		}
+		if removed := missing(oEnum, nEnum); len(removed) > 0 {
+			report(enumRemoved, "values %s removed", formatValues(removed))
+		}
+	}
+
+	// Keywords which can't be compared.
+	for _, kw := range []string{"const", "format", "pattern", "not"} {
+		ov, oHas := oSchema[kw]
+		nv, nHas := nSchema[kw]
+		switch {
+		case !oHas && nHas:
+			report(schemaNarrowed, "%s %s added", kw, formatValue(nv))
+		case oHas && !nHas:
+			report(schemaWidened, "%s %s removed", kw, formatValue(ov))
+		case oHas && !reflect.DeepEqual(ov, nv):
+			report(schemaChanged, "%s changed from %s to %s", kw, formatValue(ov), formatValue(nv))
+		}
+	}
+
+	// Bounds.
+	for _, kw := range []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"} {
+		d.bound(kw, oSchema, nSchema, report, true)
+	}
+	for _, kw := range []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"} {
+		d.bound(kw, oSchema, nSchema, report, false)
+	}
+
+	// Object fields.
+	d.properties(method, loc, oSchema, nSchema, dir)
+	oAdditional, nAdditional := oSchema["additionalProperties"], nSchema["additionalProperties"]
+	switch {
+	case oAdditional == false && nAdditional != false:
+		report(schemaWidened, "additional fields allowed")
+	case oAdditional != false && nAdditional == false:
+		report(schemaNarrowed, "additional fields no longer allowed")
+	case isObject(oAdditional) && isObject(nAdditional):
+		d.schema(method, loc+".*", oAdditional, nAdditional, dir)
+	}
+
+	// Array items.
+	if isObject(oSchema["items"]) || isObject(nSchema["items"]) {
+		d.schema(method, loc+"[]", oSchema["items"], nSchema["items"], dir)
+	}
+}
+
+// bound compares a numeric bound of the old and new schema. For lower bounds,
+// raising the bound narrows the schema.
+func (d *differ) bound(kw string, oSchema, nSchema object, report func(kind, string, ...any), lower bool) {
+	ov, oHas := oSchema[kw].(float64)
+	nv, nHas := nSchema[kw].(float64)
+	if !lower {
+		ov, nv = -ov, -nv
+	}
+	switch {
+	case !oHas && nHas:
+		report(schemaNarrowed, "%s %v added", kw, nSchema[kw])
+	case oHas && !nHas:
+		report(schemaWidened, "%s %v removed", kw, oSchema[kw])
+	case nv > ov:
+		report(schemaNarrowed, "%s changed from %v to %v", kw, oSchema[kw], nSchema[kw])
+	case nv < ov:
+		report(schemaWidened, "%s changed from %v to %v", kw, oSchema[kw], nSchema[kw])
+	}
+}
+
+// properties compares the fields of object schemas.
+func (d *differ) properties(method, loc string, oSchema, nSchema object, dir direction) {
+	oProps, _ := oSchema["properties"].(object)
+	nProps, _ := nSchema["properties"].(object)
+	oRequired, nRequired := requiredFields(oSchema), requiredFields(nSchema)
+
+	var removed, added []string
+	for _, name := range slices.Sorted(maps.Keys(oProps)) {
+		if _, ok := nProps[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(nProps)) {
+		if _, ok := oProps[name]; !ok {
+			added = append(added, name)
+		}
+	}
+
+	// A field removed while another field with the same schema is added is
+	// reported as a rename, but only if neither field matches any other
+	// candidate. Otherwise the fields are reported as removed and added.
+	matches := make(map[string][]string)
+	for _, r := range removed {
+		for _, a := range added {
+			if oRequired[r] == nRequired[a] && d.same(oProps[r], nProps[a], dir) {
+				matches[r] = append(matches[r], a)
+				matches[a] = append(matches[a], r)
+			}
+		}
+	}
+	renamed := make(map[string]bool)
+	for _, r := range removed {
+		if len(matches[r]) != 1 {
+			continue
+		}
+		a := matches[r][0]
+		if len(matches[a]) != 1 {
+			continue
+		}
+		renamed[r], renamed[a] = true, true
+		d.add(change{Kind: fieldRenamed, Method: method, Location: loc, Detail: fmt.Sprintf("field %s renamed to %s", r, a), Breaking: true})
+	}
+	for _, r := range removed {
+		if !renamed[r] {
+			d.add(change{Kind: fieldRemoved, Method: method, Location: loc, Detail: fmt.Sprintf("field %s removed", r), Breaking: true})
+		}
+	}
+	for _, a := range added {
+		if renamed[a] {
+			continue
+		}
+		req := "optional"
+		if nRequired[a] {
+			req = "required"
+		}
+		// A new required field in a request breaks existing callers.
+		d.add(change{Kind: fieldAdded, Method: method, Location: loc, Detail: fmt.Sprintf("%s field %s added", req, a), Breaking: nRequired[a] && dir == request})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(oProps)) {
+		if _, ok := nProps[name]; !ok {
+			continue
+		}
+		switch {
+		case !oRequired[name] && nRequired[name]:
+			d.add(change{Kind: schemaNarrowed, Method: method, Location: loc, Detail: fmt.Sprintf("field %s is now required", name), Breaking: dir.breaking(schemaNarrowed)})
+		case oRequired[name] && !nRequired[name]:
+			d.add(change{Kind: schemaWidened, Method: method, Location: loc, Detail: fmt.Sprintf("field %s is now optional", name), Breaking: dir.breaking(schemaWidened)})
+		}
+		d.schema(method, loc+"."+name, oProps[name], nProps[name], dir)
+	}
+}
+
+// alternatives compares the oneOf/anyOf alternatives of two schemas.
+// Alternatives are matched up if they are the same, have the same title, or
+// are the only ones left unmatched.
+func (d *differ) alternatives(method, loc string, oAlts, nAlts []any, dir direction) {
+	matched := make([]int, len(oAlts))
+	used := make([]bool, len(nAlts))
+	for i := range matched {
+		matched[i] = -1
+	}
+	match := func(pred func(o, n any) bool) {
+		for i, o := range oAlts {
+			if matched[i] >= 0 {
+				continue
+			}
+			for j, n := range nAlts {
+				if !used[j] && pred(o, n) {
+					matched[i], used[j] = j, true
+					break
+				}
+			}
+		}
+	}
+	match(func(o, n any) bool { return d.same(o, n, dir) })
+	match(func(o, n any) bool {
+		ot, nt := d.title(d.old, o), d.title(d.new, n)
+		return ot != "" && ot == nt
+	})
+	var oLeft, nLeft []int
+	for i := range oAlts {
+		if matched[i] < 0 {
+			oLeft = append(oLeft, i)
+		}
+	}
+	for j := range nAlts {
+		if !used[j] {
+			nLeft = append(nLeft, j)
+		}
+	}
+	if len(oLeft) == 1 && len(nLeft) == 1 {
+		matched[oLeft[0]], used[nLeft[0]] = nLeft[0], true
+		oLeft, nLeft = nil, nil
+	}
+
+	for i, j := range matched {
+		if j >= 0 {
+			d.schema(method, loc, oAlts[i], nAlts[j], dir)
+		}
+	}
+	for _, j := range nLeft {
+		d.add(change{Kind: schemaWidened, Method: method, Location: loc, Detail: fmt.Sprintf("alternative %s added", d.describe(d.new, nAlts[j])), Breaking: dir.breaking(schemaWidened)})
+	}
+	for _, i := range oLeft {
+		d.add(change{Kind: schemaNarrowed, Method: method, Location: loc, Detail: fmt.Sprintf("alternative %s removed", d.describe(d.old, oAlts[i])), Breaking: dir.breaking(schemaNarrowed)})
+	}
+}
+
+// same reports whether two schemas are equivalent.
+func (d *differ) same(o, n any, dir direction) bool {
+	sub := &differ{old: d.old, new: d.new, visiting: d.visiting}
+	sub.schema("", "", o, n, dir)
+	return len(sub.changes) == 0
+}
+
+// title returns the title of a schema, or the name of the component it
+// references.
+func (d *differ) title(doc *document, v any) string {
+	if ref, ok := asObject(v)["$ref"].(string); ok {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	s, _ := doc.resolve(v)
+	title, _ := s["title"].(string)
+	return title
+}
+
+// describe names a schema in a change.
+func (d *differ) describe(doc *document, v any) string {
+	if title := d.title(doc, v); title != "" {
+		return title
+	}
+	s, _ := doc.schema(v)
+	if t := types(s); t != nil {
+		return strings.Join(t, "|")
+	}
+	return "schema"
+}
+
+// alternatives returns the alternatives of a schema and the keyword, oneOf or
+// anyOf, declaring them.
+func alternatives(s object) (string, []any) {
+	if alts, ok := s["oneOf"].([]any); ok {
+		return "oneOf", alts
+	}
+	alts, _ := s["anyOf"].([]any)
+	return "anyOf", alts
+}
+
+// types returns the types allowed by a schema, or nil if any type is.
+func types(s object) []string {
+	switch t := s["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var out []string
+		for _, v := range t {
+			if str, ok := v.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func requiredFields(s object) map[string]bool {
+	required, _ := s["required"].([]any)
+	fields := make(map[string]bool, len(required))
+	for _, r := range required {
+		if name, ok := r.(string); ok {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// missing returns the values in a which aren't in b.
+func missing(a, b []any) []any {
+	var out []any
+	for _, v := range a {
+		if !slices.ContainsFunc(b, func(w any) bool { return reflect.DeepEqual(v, w) }) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func formatValue(v any) string {
+	enc, _ := json.Marshal(v)
+	return string(enc)
+}
+
+func formatValues(values []any) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = formatValue(v)
+	}
+	return strings.Join(s, ", ")
+}
+
+func asObject(v any) object {
+	obj, _ := v.(object)
+	return obj
+}
+
+func isObject(v any) bool {
+	_, ok := v.(object)
+	return ok
+}
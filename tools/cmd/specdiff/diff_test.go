@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testDocument writes a spec with the given methods and components and reads
+// it back.
+func testDocument(t *testing.T, methods, components string) *document {
+	t.Helper()
+	if components == "" {
+		components = "{}"
+	}
+	content := fmt.Sprintf(`{"openrpc":"1.2.4","methods":[%s],"components":%s}`, methods, components)
+	file := filepath.Join(t.TempDir(), "openrpc.json")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := readDocument(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// paramMethod is a method taking a single required param with the schema.
+func paramMethod(schema string) string {
+	return fmt.Sprintf(`{"name":"m","params":[{"name":"p","required":true,"schema":%s}],"result":{"name":"r","schema":{}}}`, schema)
+}
+
+// resultMethod is a method without params returning the schema.
+func resultMethod(schema string) string {
+	return fmt.Sprintf(`{"name":"m","params":[],"result":{"name":"r","schema":%s}}`, schema)
+}
+
+// checkChange checks that the diff holds exactly one change of the given kind.
+func checkChange(t *testing.T, changes []change, k kind, breaking bool) {
+	t.Helper()
+	if len(changes) != 1 {
+		t.Fatalf("got %d changes, want 1: %v", len(changes), changes)
+	}
+	c := changes[0]
+	if c.Kind != k {
+		t.Errorf("kind = %s, want %s (%v)", c.Kind, k, c)
+	}
+	if c.Breaking != breaking {
+		t.Errorf("breaking = %v, want %v (%v)", c.Breaking, breaking, c)
+	}
+}
+
+func TestDiffSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		kind     kind
+		// whether the change is breaking in a param and in a result
+		breakingRequest, breakingResponse bool
+	}{
+		{
+			name: "type added",
+			old:  `{"type":"string"}`,
+			new:  `{"type":["string","null"]}`,
+			kind: schemaWidened, breakingResponse: true,
+		},
+		{
+			name: "type removed",
+			old:  `{"type":["string","null"]}`,
+			new:  `{"type":"string"}`,
+			kind: schemaNarrowed, breakingRequest: true,
+		},
+		{
+			name: "type restricted",
+			old:  `{}`,
+			new:  `{"type":"string"}`,
+			kind: schemaNarrowed, breakingRequest: true,
+		},
+		{
+			name: "pattern removed",
+			old:  `{"type":"string","pattern":"^0x"}`,
+			new:  `{"type":"string"}`,
+			kind: schemaWidened, breakingResponse: true,
+		},
+		{
+			name: "pattern changed",
+			old:  `{"type":"string","pattern":"^0x"}`,
+			new:  `{"type":"string","pattern":"^0X"}`,
+			kind: schemaChanged, breakingRequest: true, breakingResponse: true,
+		},
+		{
+			name: "minimum raised",
+			old:  `{"type":"integer","minimum":0}`,
+			new:  `{"type":"integer","minimum":1}`,
+			kind: schemaNarrowed, breakingRequest: true,
+		},
+		{
+			name: "maxItems raised",
+			old:  `{"type":"array","maxItems":1}`,
+			new:  `{"type":"array","maxItems":2}`,
+			kind: schemaWidened, breakingResponse: true,
+		},
+		{
+			name: "enum value added",
+			old:  `{"enum":["a"]}`,
+			new:  `{"enum":["a","b"]}`,
+			kind: enumAdded, breakingResponse: true,
+		},
+		{
+			name: "enum value removed",
+			old:  `{"enum":["a","b"]}`,
+			new:  `{"enum":["a"]}`,
+			kind: enumRemoved, breakingRequest: true,
+		},
+		{
+			name: "alternative added",
+			old:  `{"oneOf":[{"type":"string"}]}`,
+			new:  `{"oneOf":[{"type":"string"},{"type":"null"}]}`,
+			kind: schemaWidened, breakingResponse: true,
+		},
+		{
+			name: "alternative removed",
+			old:  `{"anyOf":[{"type":"string"},{"type":"null"}]}`,
+			new:  `{"anyOf":[{"type":"string"}]}`,
+			kind: schemaNarrowed, breakingRequest: true,
+		},
+		{
+			name: "oneOf changed to anyOf",
+			old:  `{"oneOf":[{"title":"A","type":"object"},{"title":"B","type":"object"}]}`,
+			new:  `{"anyOf":[{"title":"A","type":"object"},{"title":"B","type":"object"}]}`,
+			kind: keywordChanged, breakingResponse: true,
+		},
+		{
+			name: "anyOf changed to oneOf",
+			old:  `{"anyOf":[{"type":"string"},{"type":"null"}]}`,
+			new:  `{"oneOf":[{"type":"string"},{"type":"null"}]}`,
+			kind: keywordChanged, breakingRequest: true,
+		},
+		{
+			name: "optional field added",
+			old:  `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			new:  `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"string"}}}`,
+			kind: fieldAdded,
+		},
+		{
+			name: "required field added",
+			old:  `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			new:  `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"string"}},"required":["b"]}`,
+			kind: fieldAdded, breakingRequest: true,
+		},
+		{
+			name: "field removed",
+			old:  `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"integer"}}}`,
+			new:  `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			kind: fieldRemoved, breakingRequest: true, breakingResponse: true,
+		},
+		{
+			name: "field renamed",
+			old:  `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			new:  `{"type":"object","properties":{"b":{"type":"string"}}}`,
+			kind: fieldRenamed, breakingRequest: true, breakingResponse: true,
+		},
+		{
+			name: "field made required",
+			old:  `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			new:  `{"type":"object","properties":{"a":{"type":"string"}},"required":["a"]}`,
+			kind: schemaNarrowed, breakingRequest: true,
+		},
+		{
+			name: "additional fields allowed",
+			old:  `{"type":"object","additionalProperties":false}`,
+			new:  `{"type":"object"}`,
+			kind: schemaWidened, breakingResponse: true,
+		},
+		{
+			name: "nested item type added",
+			old:  `{"type":"array","items":{"type":"string"}}`,
+			new:  `{"type":"array","items":{"type":["string","null"]}}`,
+			kind: schemaWidened, breakingResponse: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"/request", func(t *testing.T) {
+			old := testDocument(t, paramMethod(tt.old), "")
+			new := testDocument(t, paramMethod(tt.new), "")
+			checkChange(t, diff(old, new), tt.kind, tt.breakingRequest)
+		})
+		t.Run(tt.name+"/response", func(t *testing.T) {
+			old := testDocument(t, resultMethod(tt.old), "")
+			new := testDocument(t, resultMethod(tt.new), "")
+			checkChange(t, diff(old, new), tt.kind, tt.breakingResponse)
+		})
+	}
+}
+
+func TestDiffAmbiguousRename(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string // change details
+	}{
+		{
+			name: "two candidates for one field",
+			old:  `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			new:  `{"type":"object","properties":{"b":{"type":"string"},"c":{"type":"string"}}}`,
+			want: []string{"field a removed", "optional field b added", "optional field c added"},
+		},
+		{
+			name: "two fields for two candidates",
+			old:  `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"string"}}}`,
+			new:  `{"type":"object","properties":{"c":{"type":"string"},"d":{"type":"string"}}}`,
+			want: []string{"field a removed", "field b removed", "optional field c added", "optional field d added"},
+		},
+		{
+			name: "unique pairing next to other changes",
+			old:  `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"integer"}}}`,
+			new:  `{"type":"object","properties":{"c":{"type":"string"},"d":{"type":"boolean"}}}`,
+			want: []string{"field a renamed to c", "field b removed", "optional field d added"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := testDocument(t, resultMethod(tt.old), "")
+			new := testDocument(t, resultMethod(tt.new), "")
+			var got []string
+			for _, c := range diff(old, new) {
+				got = append(got, c.Detail)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got changes %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffMethod(t *testing.T) {
+	const (
+		noParams  = `{"name":"m","params":[],"result":{"name":"r","schema":{}}}`
+		reqParam  = `{"name":"m","params":[{"name":"p","required":true,"schema":{}}],"result":{"name":"r","schema":{}}}`
+		optParam  = `{"name":"m","params":[{"name":"p","schema":{}}],"result":{"name":"r","schema":{}}}`
+		renamed   = `{"name":"m","params":[{"name":"q","required":true,"schema":{}}],"result":{"name":"r","schema":{}}}`
+		withError = `{"name":"m","params":[],"result":{"name":"r","schema":{}},"errors":[{"code":4444,"message":"Pruned history unavailable"}]}`
+		otherM    = `{"name":"n","params":[],"result":{"name":"r","schema":{}}}`
+	)
+	tests := []struct {
+		name     string
+		old, new string
+		kind     kind
+		breaking bool
+	}{
+		{"method added", noParams, noParams + "," + otherM, methodAdded, false},
+		{"method removed", noParams + "," + otherM, noParams, methodRemoved, true},
+		{"required param added", noParams, reqParam, paramAdded, true},
+		{"optional param added", noParams, optParam, paramAdded, false},
+		{"param removed", optParam, noParams, paramRemoved, true},
+		{"param renamed", reqParam, renamed, paramRenamed, false}, // params are sent by position
+		{"param made required", optParam, reqParam, paramRequired, true},
+		{"param made optional", reqParam, optParam, paramRequired, false},
+		{"error added", noParams, withError, errorAdded, false},
+		{"error removed", withError, noParams, errorRemoved, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := testDocument(t, tt.old, "")
+			new := testDocument(t, tt.new, "")
+			checkChange(t, diff(old, new), tt.kind, tt.breaking)
+		})
+	}
+}
+
+func TestDiffRefs(t *testing.T) {
+	// The same change, once dereferenced and once through components.
+	const components = `{"schemas":{"Payload":{"title":"Payload","type":"object","properties":{"a":{"type":"string"}}}}}`
+	old := testDocument(t, paramMethod(`{"oneOf":[{"$ref":"#/components/schemas/Payload"},{"type":"null"}]}`), components)
+	new := testDocument(t, paramMethod(`{"anyOf":[{"title":"Payload","type":"object","properties":{"a":{"type":"string"}}},{"type":"null"}]}`), "")
+	checkChange(t, diff(old, new), keywordChanged, false)
+
+	// Recursive schemas must terminate.
+	const recursive = `{"schemas":{"Node":{"type":"object","properties":{"next":{"$ref":"#/components/schemas/Node"}}}}}`
+	doc := testDocument(t, resultMethod(`{"$ref":"#/components/schemas/Node"}`), recursive)
+	if changes := diff(doc, doc); len(changes) != 0 {
+		t.Errorf("changes in identical recursive spec: %v", changes)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alexflint/go-arg"
+)
+
+type Args struct {
+	Old            string `arg:"positional,required" help:"path to the old spec"`
+	New            string `arg:"positional,required" help:"path to the new spec"`
+	Format         string `arg:"--format" help:"output format (text, markdown or json)" default:"text"`
+	FailOnBreaking bool   `arg:"--fail-on-breaking" help:"exit with an error if there are breaking changes"`
+}
+
+func main() {
+	var args Args
+	arg.MustParse(&args)
+	if err := run(&args); err != nil {
+		exit(err)
+	}
+}
+
+func run(args *Args) error {
+	if args.Format != "text" && args.Format != "markdown" && args.Format != "json" {
+		return fmt.Errorf("unknown output format: %s", args.Format)
+	}
+	old, err := readDocument(args.Old)
+	if err != nil {
+		return err
+	}
+	new, err := readDocument(args.New)
+	if err != nil {
+		return err
+	}
+
+	changes := diff(old, new)
+	switch args.Format {
+	case "json":
+		if err := writeJSON(os.Stdout, changes); err != nil {
+			return err
+		}
+	case "markdown":
+		writeMarkdown(os.Stdout, changes)
+	default:
+		writeText(os.Stdout, changes)
+	}
+	if n := countBreaking(changes); args.FailOnBreaking && n > 0 {
+		return fmt.Errorf("%d breaking changes", n)
+	}
+	return nil
+}
+
+func exit(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
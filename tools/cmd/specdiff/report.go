@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// byKind groups changes by kind, in the order kinds are reported.
+func byKind(changes []change) [][]change {
+	var groups [][]change
+	for _, k := range kinds {
+		var group []change
+		for _, c := range changes {
+			if c.Kind == k {
+				group = append(group, c)
+			}
+		}
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+func countBreaking(changes []change) int {
+	n := 0
+	for _, c := range changes {
+		if c.Breaking {
+			n++
+		}
+	}
+	return n
+}
+
+// writeText writes the changes grouped by kind. Breaking changes are marked
+// with "!".
+func writeText(w io.Writer, changes []change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no changes.")
+		return
+	}
+	for _, group := range byKind(changes) {
+		fmt.Fprintf(w, "%s:\n", group[0].Kind)
+		for _, c := range group {
+			mark := " "
+			if c.Breaking {
+				mark = "!"
+			}
+			fmt.Fprintf(w, "%s %s\n", mark, c)
+		}
+	}
+	fmt.Fprintf(w, "\n%d changes, %d breaking (marked !).\n", len(changes), countBreaking(changes))
+}
+
+// writeMarkdown writes the changes as lists for release notes, breaking
+// changes first.
+func writeMarkdown(w io.Writer, changes []change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes to the spec.")
+		return
+	}
+	section := func(title string, breaking bool) {
+		var list []change
+		for _, c := range changes {
+			if c.Breaking == breaking {
+				list = append(list, c)
+			}
+		}
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "### %s\n\n", title)
+		for _, group := range byKind(list) {
+			for _, c := range group {
+				loc := ""
+				if c.Location != "" {
+					loc = " `" + c.Location + "`"
+				}
+				fmt.Fprintf(w, "- `%s`%s: %s\n", c.Method, loc, c.Detail)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	section("Breaking changes", true)
+	section("Other changes", false)
+}
+
+func writeJSON(w io.Writer, changes []change) error {
+	if changes == nil {
+		changes = []change{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(changes)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-jsonpointer"
+)
+
+type object = map[string]any
+
+// document is an OpenRPC document. Both dereferenced documents and documents
+// using $ref to components are supported; references are resolved as the
+// document is compared.
+type document struct {
+	raw     object
+	methods map[string]object
+}
+
+func readDocument(file string) (*document, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read spec: %v", err)
+	}
+	var raw object
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("unable to read spec %s: %v", file, err)
+	}
+	doc := &document{raw: raw, methods: make(map[string]object)}
+	methods, _ := raw["methods"].([]any)
+	for i, m := range methods {
+		method, ok := doc.resolve(m)
+		if !ok {
+			return nil, fmt.Errorf("%s: method %d: unresolvable $ref", file, i)
+		}
+		name, _ := method["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("%s: method %d has no name", file, i)
+		}
+		doc.methods[name] = method
+	}
+	return doc, nil
+}
+
+// maxRefs bounds the length of a chain of references, guarding against
+// references to themselves.
+const maxRefs = 32
+
+// resolve follows $ref in v to the referenced object. Only references within
+// the document are supported. As in specgen, keys next to a $ref override
+// those of the referenced object.
+func (d *document) resolve(v any) (object, bool) {
+	return d.resolveDepth(v, 0)
+}
+
+func (d *document) resolveDepth(v any, depth int) (object, bool) {
+	obj, ok := v.(object)
+	if !ok || depth > maxRefs {
+		return nil, false
+	}
+	ref, isRef := obj["$ref"].(string)
+	if !isRef {
+		return obj, true
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	target, err := jsonpointer.Get(d.raw, strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil, false
+	}
+	base, ok := d.resolveDepth(target, depth+1)
+	if !ok || len(obj) == 1 {
+		return base, ok
+	}
+	out := make(object, len(base)+len(obj))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range obj {
+		if k != "$ref" {
+			out[k] = v
+		}
+	}
+	return out, true
+}
+
+// schema resolves a schema and merges any allOf into it, so schemas can be
+// compared keyword by keyword. Properties and required fields of the allOf
+// entries are merged, other keywords are only taken from an entry if the
+// schema doesn't have them.
+func (d *document) schema(v any) (object, bool) {
+	s, ok := d.resolve(v)
+	if !ok {
+		return nil, false
+	}
+	allOf, ok := s["allOf"].([]any)
+	if !ok {
+		return s, true
+	}
+	out := make(object, len(s))
+	for k, v := range s {
+		if k != "allOf" {
+			out[k] = v
+		}
+	}
+	for _, entry := range allOf {
+		sub, ok := d.schema(entry)
+		if !ok {
+			return nil, false
+		}
+		for k, v := range sub {
+			switch k {
+			case "properties":
+				props, _ := out["properties"].(object)
+				merged := make(object, len(props))
+				for name, p := range props {
+					merged[name] = p
+				}
+				add, _ := v.(object)
+				for name, p := range add {
+					if _, exists := merged[name]; !exists {
+						merged[name] = p
+					}
+				}
+				out["properties"] = merged
+			case "required":
+				req, _ := out["required"].([]any)
+				add, _ := v.([]any)
+				out["required"] = append(append([]any{}, req...), add...)
+			default:
+				if _, exists := out[k]; !exists {
+					out[k] = v
+				}
+			}
+		}
+	}
+	return out, true
+}
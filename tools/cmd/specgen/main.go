@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"io/fs"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/ethereum/execution-apis/tools/internal/specgen"
@@ -15,7 +18,12 @@ var methodFilesFlag = []string{}
 var schemaFilesFlag = []string{}
 var errorGroupFilesFlag = []string{}
 var outputFile = ""
+var checkFile = ""
 var dereferencing bool
+var failOnUnused bool
+var splitBy = ""
+var lang = "openrpc"
+var packageName = "spec"
 
 func init() {
 	flag.Func("methods", "path to method files (glob syntax, repeatable)", func(s string) error {
@@ -32,13 +40,55 @@ func init() {
 	})
 	flag.StringVar(&outputFile, "output", "", "output file")
 	flag.StringVar(&outputFile, "o", "", "output file")
+	flag.StringVar(&checkFile, "check", "", "check that the given output file is up to date instead of writing it")
 	flag.BoolVar(&dereferencing, "deref", false, "Enable dereferencing of spec")
+	flag.BoolVar(&failOnUnused, "fail-on-unused", false, "Fail if any schema is not used by a method")
+	flag.StringVar(&splitBy, "split", "", "also write the spec split by \"namespace\", \"fork\" or \"namespace,fork\"")
+	flag.StringVar(&lang, "lang", "openrpc", "output language (\"openrpc\", \"go\" or \"ts\")")
+	flag.StringVar(&packageName, "package", "spec", "package name of generated bindings")
 }
 
 func main() {
 	flag.Parse()
 	log.SetFlags(0)
 
+	if checkFile != "" {
+		if outputFile != "" {
+			log.Fatal("-check can't be used with -o")
+		}
+		outputFile = checkFile
+	}
+
+	var splitByNamespace, splitByFork bool
+	if splitBy != "" {
+		for _, by := range strings.Split(splitBy, ",") {
+			switch by {
+			case "namespace":
+				splitByNamespace = true
+			case "fork":
+				splitByFork = true
+			default:
+				log.Fatalf("invalid -split %q, must be namespace or fork", by)
+			}
+		}
+		if outputFile == "" {
+			log.Fatal("-split requires an output file")
+		}
+	}
+
+	switch lang {
+	case "openrpc":
+	case "go", "ts":
+		if outputFile == "" {
+			log.Fatalf("-lang %s requires an output file", lang)
+		}
+		if dereferencing || splitBy != "" {
+			log.Fatalf("-lang %s can't be used with -deref or -split", lang)
+		}
+	default:
+		log.Fatalf("invalid -lang %q, must be openrpc, go or ts", lang)
+	}
+
 	var methodFiles []string
 	for _, file := range methodFilesFlag {
 		info, err := os.Stat(file)
@@ -98,8 +148,8 @@ func main() {
 		if err != nil {
 			log.Fatal("can't read method file:", err)
 		}
-		if err := sg.AddMethods(content); err != nil {
-			log.Fatalf("error in %s: %v", file, err)
+		if err := sg.AddMethods(file, content); err != nil {
+			log.Fatal(err)
 		}
 		log.Println("added methods from", file)
 	}
@@ -109,8 +159,8 @@ func main() {
 			log.Fatal("can't read schema file:", err)
 			os.Exit(1)
 		}
-		if err := sg.AddSchemas(content); err != nil {
-			log.Fatalf("error in %s: %v", file, err)
+		if err := sg.AddSchemas(file, content); err != nil {
+			log.Fatal(err)
 		}
 		log.Println("added schemas from", file)
 	}
@@ -120,17 +170,66 @@ func main() {
 		if err != nil {
 			log.Fatal("can't read error group file:", err)
 		}
-		if err := sg.AddErrorGroups(content); err != nil {
-			log.Fatalf("error in %s: %v", file, err)
+		if err := sg.AddErrorGroups(file, content); err != nil {
+			log.Fatal(err)
 		}
 		log.Println("added error groups from", file)
 	}
 
 	// Resolve error groups into flat errors per method.
 	if len(errorGroupFiles) > 0 {
-		if err := sg.ResolveErrorGroups(); err != nil {
+		overrides, err := sg.ResolveErrorGroups()
+		if err != nil {
 			log.Fatal(err)
 		}
+		for _, err := range overrides {
+			log.Println(err)
+		}
+	}
+
+	// Check schema references. This happens before dereferencing, so that
+	// unresolved references are reported in both modes.
+	if err := sg.CheckRefs(); err != nil {
+		log.Fatal(err)
+	}
+	if err := sg.CheckForks(); err != nil {
+		log.Fatal(err)
+	}
+	if unused := sg.UnusedSchemas(); len(unused) > 0 {
+		for _, err := range unused {
+			log.Println(err)
+		}
+		if failOnUnused {
+			log.Fatalf("%d unused schemas", len(unused))
+		}
+	}
+
+	// Generate bindings if requested. This happens on the spec with references,
+	// which become named types.
+	if lang != "openrpc" {
+		if err := sg.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		var code []byte
+		var err error
+		if lang == "go" {
+			code, err = sg.Go(packageName)
+		} else {
+			code, err = sg.TypeScript()
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		writeOutput(outputFile, code, "bindings")
+		return
+	}
+
+	// Split the spec if requested. This happens before dereferencing, which
+	// removes the references used to find the schemas of each part.
+	var parts map[string]*specgen.Generator
+	if splitBy != "" {
+		parts = sg.Split(splitByNamespace, splitByFork)
+		delete(parts, "") // the whole spec
 	}
 
 	// Dereference the spec if requested.
@@ -138,6 +237,11 @@ func main() {
 		if err := sg.Dereference(); err != nil {
 			log.Fatal(err)
 		}
+		for _, part := range parts {
+			if err := part.Dereference(); err != nil {
+				log.Fatal(err)
+			}
+		}
 	}
 
 	// Write output.
@@ -145,22 +249,65 @@ func main() {
 		log.Printf("no output file specified, just validating spec")
 		err := sg.Validate()
 		if err != nil {
-			log.Println("spec is invalid:", err)
+			log.Println(err)
 			os.Exit(1)
 		} else {
 			log.Println("spec is valid")
 			os.Exit(0)
 		}
 	} else {
-		outputBytes, err := sg.JSON()
-		if err != nil {
-			log.Fatal(err)
+		writeSpec(outputFile, sg)
+		for _, name := range slices.Sorted(maps.Keys(parts)) {
+			writeSpec(partFile(outputFile, name), parts[name])
+		}
+	}
+}
+
+func writeSpec(file string, sg *specgen.Generator) {
+	outputBytes, err := sg.JSON()
+	if err != nil {
+		log.Fatal(err)
+	}
+	writeOutput(file, outputBytes, "spec")
+}
+
+// writeOutput writes the generated content to file. With -check, it instead
+// verifies that file holds the content and fails if it doesn't.
+func writeOutput(file string, content []byte, what string) {
+	if checkFile == "" {
+		if err := os.WriteFile(file, content, 0644); err != nil {
+			log.Fatalf("%s write failed: %v", what, err)
 		}
-		if err := os.WriteFile(outputFile, outputBytes, 0644); err != nil {
-			log.Fatal("spec write failed:", err)
+		log.Printf("wrote %s to %s", what, file)
+		return
+	}
+	current, err := os.ReadFile(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !bytes.Equal(current, content) {
+		log.Fatalf("%s is out of date at line %d, regenerate it", file, firstDifference(current, content))
+	}
+	log.Println(file, "is up to date")
+}
+
+// firstDifference returns the first line at which a and b differ.
+func firstDifference(a, b []byte) int {
+	line := 1
+	for i := 0; i < len(a) && i < len(b) && a[i] == b[i]; i++ {
+		if a[i] == '\n' {
+			line++
 		}
-		log.Println("wrote spec to", outputFile)
 	}
+	return line
+}
+
+// partFile returns the file a part of the split spec is written to, which is
+// named after the part, e.g. openrpc-eth.json for the part "eth" of
+// openrpc.json.
+func partFile(file, part string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + part + ext
 }
 
 func addFilesWithExt(list *[]string, ext string) fs.WalkDirFunc {
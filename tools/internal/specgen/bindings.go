@@ -0,0 +1,255 @@
+package specgen
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-jsonpointer"
+)
+
+// This file holds the parts of the bindings generators which don't depend on
+// the target language. Bindings are generated from the schemas before they are
+// dereferenced, so references to component schemas become references to named
+// types.
+
+// lookupRef returns the schema referenced by a $ref to a component schema.
+func (s *Generator) lookupRef(ref string) (object, error) {
+	name, path, err := parseSchemaRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	schema, ok := s.types[name]
+	if !ok {
+		return nil, fmt.Errorf("$ref %q: schema %s not found", ref, name)
+	}
+	if path == "" {
+		return schema, nil
+	}
+	target, err := jsonpointer.Get(schema, path)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %q: %s not found in schema %s", ref, path, name)
+	}
+	obj, ok := target.(object)
+	if !ok {
+		return nil, fmt.Errorf("$ref %q: not a schema", ref)
+	}
+	return obj, nil
+}
+
+// plainRef returns the $ref of schema if the schema is nothing but a
+// reference, i.e. it has no keywords next to the $ref other than annotations.
+func plainRef(schema object) (string, bool) {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return "", false
+	}
+	for k := range schema {
+		switch k {
+		case "$ref", "title", "description", "examples", "deprecated", sinceForkKey, deprecatedKey:
+		default:
+			return "", false
+		}
+	}
+	return ref, true
+}
+
+// expandRef returns schema with its $ref replaced by the keywords of the
+// referenced schema. As in Dereference, keywords next to the $ref take
+// precedence.
+func (s *Generator) expandRef(schema object) (object, error) {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema, nil
+	}
+	base, err := s.lookupRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	base, err = s.expandRef(base)
+	if err != nil {
+		return nil, err
+	}
+	out := maps.Clone(base)
+	for k, v := range schema {
+		if k != "$ref" {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// flattenAllOf merges the entries of an allOf into schema, as mergeAllOf does,
+// but without dereferencing the properties. Entries which are themselves a
+// choice of objects contribute the properties of every alternative, and only
+// the fields required by all of them are required.
+func (s *Generator) flattenAllOf(schema object) (object, error) {
+	allOf, ok := schema["allOf"].([]any)
+	if !ok {
+		return schema, nil
+	}
+	out := make(object, len(schema))
+	for k, v := range schema {
+		if k != "allOf" {
+			out[k] = v
+		}
+	}
+	// mergeSchemas modifies these in place.
+	if props, ok := out["properties"].(object); ok {
+		out["properties"] = maps.Clone(props)
+	}
+	if required, ok := out["required"].([]any); ok {
+		out["required"] = slices.Clone(required)
+	}
+	for _, entry := range allOf {
+		sub, ok := entry.(object)
+		if !ok {
+			continue
+		}
+		sub, err := s.objectSchema(sub)
+		if err != nil {
+			return nil, err
+		}
+		mergeSchemas(out, sub)
+	}
+	return out, nil
+}
+
+// objectSchema resolves schema into a single object schema, expanding $ref and
+// allOf, and merging the alternatives of a choice.
+func (s *Generator) objectSchema(schema object) (object, error) {
+	schema, err := s.expandRef(schema)
+	if err != nil {
+		return nil, err
+	}
+	schema, err = s.flattenAllOf(schema)
+	if err != nil {
+		return nil, err
+	}
+	alts := alternatives(schema)
+	if alts == nil {
+		return schema, nil
+	}
+	out := make(object)
+	var required []any
+	for i, alt := range alts {
+		sub, ok := alt.(object)
+		if !ok {
+			continue
+		}
+		sub, err := s.objectSchema(sub)
+		if err != nil {
+			return nil, err
+		}
+		props, _ := sub["properties"].(object)
+		if len(props) == 0 {
+			continue
+		}
+		if out["properties"] == nil {
+			out["properties"] = make(object)
+			out["type"] = "object"
+		}
+		for name, p := range props {
+			if _, exists := out["properties"].(object)[name]; !exists {
+				out["properties"].(object)[name] = p
+			}
+		}
+		// Only fields required by every alternative are required.
+		req, _ := sub["required"].([]any)
+		if i == 0 {
+			required = slices.Clone(req)
+		} else {
+			required = slices.DeleteFunc(required, func(r any) bool { return !slices.Contains(req, r) })
+		}
+	}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out, nil
+}
+
+// alternatives returns the oneOf or anyOf alternatives of a schema.
+func alternatives(schema object) []any {
+	if alts, ok := schema["oneOf"].([]any); ok {
+		return alts
+	}
+	alts, _ := schema["anyOf"].([]any)
+	return alts
+}
+
+// isNull reports whether schema only allows null.
+func isNull(v any) bool {
+	schema, _ := v.(object)
+	return schema["type"] == "null"
+}
+
+// requiredSet returns the required fields of an object schema.
+func requiredSet(schema object) map[string]bool {
+	required, _ := schema["required"].([]any)
+	set := make(map[string]bool, len(required))
+	for _, r := range required {
+		if name, ok := r.(string); ok {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// sortedProperties returns the property names of an object schema.
+func sortedProperties(schema object) []string {
+	props, _ := schema["properties"].(object)
+	return slices.Sorted(maps.Keys(props))
+}
+
+// nameWords splits a name from the spec, e.g. "eth_getBlockByHash",
+// "Block hash" or "INVALID_BLOCK_HASH", into words.
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			// Split before an upper case letter, unless it continues a run of
+			// upper case letters, e.g. "ID" in "chainID".
+			prevUpper := unicode.IsUpper(word[len(word)-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !prevUpper || nextLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// pascalCase joins words into an upper camel case name. Words which are
+// initialisms are written in upper case.
+func pascalCase(words []string, initialisms map[string]bool) string {
+	var b strings.Builder
+	for _, w := range words {
+		lower := strings.ToLower(w)
+		switch {
+		case initialisms[lower]:
+			b.WriteString(strings.ToUpper(w))
+		case strings.ToUpper(w) == w && len(w) > 1:
+			// All-caps words, e.g. from enum values, are title cased.
+			b.WriteString(strings.ToUpper(w[:1]) + lower[1:])
+		default:
+			b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package specgen
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// The generated document is canonical, so that regenerating it from the same
+// sources gives the same bytes and changes to the sources give minimal diffs:
+//
+//   - Object members are sorted by key. encoding/json does this for maps.
+//   - Methods are sorted by name.
+//   - The errors of a method are sorted by code, ignoring its sign so that
+//     the codes of each range ascend, e.g. -32600, -32601, then message.
+//   - Schema "required" lists are sorted and free of duplicates.
+//
+// All other arrays, like params, enum, oneOf and examples, keep the order of
+// the sources, which is significant.
+
+// canonicalize returns doc with its arrays in canonical order. Methods are
+// already sorted by build. doc is not modified.
+func canonicalize(doc object) object {
+	out := canonicalValue(doc).(object)
+	methods, _ := out["methods"].([]any)
+	for _, m := range methods {
+		method, ok := m.(object)
+		if !ok {
+			continue
+		}
+		if errs, ok := method["errors"].([]any); ok {
+			slices.SortStableFunc(errs, compareErrors)
+		}
+	}
+	return out
+}
+
+// canonicalValue returns a copy of v with the required lists of schemas
+// sorted. Examples are copied as they are.
+func canonicalValue(v any) any {
+	switch v := v.(type) {
+	case object:
+		out := make(object, len(v))
+		for k, val := range v {
+			switch k {
+			case "examples":
+				if _, isList := val.([]any); isList {
+					out[k] = val
+					continue
+				}
+				// A property named "examples".
+				out[k] = canonicalValue(val)
+			case "required":
+				if names, ok := stringList(val); ok {
+					slices.Sort(names)
+					names = slices.Compact(names)
+					required := make([]any, len(names))
+					for i, name := range names {
+						required[i] = name
+					}
+					out[k] = required
+					continue
+				}
+				// A property named "required", or a content descriptor's flag.
+				out[k] = canonicalValue(val)
+			default:
+				out[k] = canonicalValue(val)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = canonicalValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// stringList returns the elements of v if it is a list of strings.
+func stringList(v any) ([]string, bool) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, false
+	}
+	names := make([]string, len(list))
+	for i, item := range list {
+		if names[i], ok = item.(string); !ok {
+			return nil, false
+		}
+	}
+	return names, true
+}
+
+// compareErrors orders error objects by the absolute value of their code, then
+// by message. Of two codes with the same absolute value, the positive one comes
+// first.
+func compareErrors(a, b any) int {
+	ea, _ := a.(object)
+	eb, _ := b.(object)
+	ca, cb := errorCode(ea), errorCode(eb)
+	if c := cmp.Compare(math.Abs(ca), math.Abs(cb)); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(cb, ca); c != 0 {
+		return c
+	}
+	ma, _ := ea["message"].(string)
+	mb, _ := eb["message"].(string)
+	return cmp.Compare(ma, mb)
+}
+
+// errorCode returns the code of an error object, which is an int when read
+// from YAML and a float64 when read from JSON.
+func errorCode(e object) float64 {
+	switch code := e["code"].(type) {
+	case int:
+		return float64(code)
+	case float64:
+		return code
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package specgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const canonicalSchemas = `
+Thing:
+  type: object
+  required: [zeta, alpha]
+  properties:
+    zeta:
+      type: string
+      enum: [b, a]
+    alpha:
+      type: string
+    required:
+      type: object
+      required: [y, x]
+`
+
+const canonicalMethods = `
+- name: test_thing
+  params:
+    - name: Thing
+      required: true
+      schema:
+        $ref: '#/components/schemas/Thing'
+  result:
+    name: Nothing
+    schema:
+      type: 'null'
+  errors:
+    - code: -32601
+      message: b
+    - code: 4
+      message: c
+    - code: -32600
+      message: a
+    - code: -32601
+      message: a
+  examples:
+    - name: example
+      params:
+        - name: Thing
+          value:
+            zeta: b
+            alpha: x
+            required: {x: 1, y: 2}
+      result:
+        name: Nothing
+        value: null
+`
+
+func TestCanonicalOrder(t *testing.T) {
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(canonicalSchemas)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(canonicalMethods)); err != nil {
+		t.Fatal(err)
+	}
+	out, err := g.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		again, err := g.JSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, again) {
+			t.Fatal("output differs between runs")
+		}
+	}
+
+	var doc struct {
+		Components struct {
+			Schemas struct {
+				Thing struct {
+					Required   []string
+					Properties struct {
+						Zeta     struct{ Enum []string }
+						Required struct{ Required []string }
+					}
+				}
+			}
+		}
+		Methods []struct {
+			Errors []struct {
+				Code    int
+				Message string
+			}
+		}
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	thing := doc.Components.Schemas.Thing
+	if want := []string{"alpha", "zeta"}; !reflect.DeepEqual(thing.Required, want) {
+		t.Errorf("wrong required %v, want %v", thing.Required, want)
+	}
+	if want := []string{"x", "y"}; !reflect.DeepEqual(thing.Properties.Required.Required, want) {
+		t.Errorf("wrong required of property \"required\" %v, want %v", thing.Properties.Required.Required, want)
+	}
+	// Enums keep their order.
+	if want := []string{"b", "a"}; !reflect.DeepEqual(thing.Properties.Zeta.Enum, want) {
+		t.Errorf("wrong enum %v, want %v", thing.Properties.Zeta.Enum, want)
+	}
+	type methodError struct {
+		Code    int
+		Message string
+	}
+	want := []methodError{{4, "c"}, {-32600, "a"}, {-32601, "a"}, {-32601, "b"}}
+	var got []methodError
+	for _, e := range doc.Methods[0].Errors {
+		got = append(got, methodError(e))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong errors %v, want %v", got, want)
+	}
+}
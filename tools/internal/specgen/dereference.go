@@ -1,7 +1,9 @@
 package specgen
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/mattn/go-jsonpointer"
@@ -21,10 +23,49 @@ func dereference(schema object, repository schemaRepository) (object, error) {
 	return d.object(schema)
 }
 
+// derefError is an error expanding a $ref. It records where the $ref is.
+type derefError struct {
+	schema  string // repository schema containing the $ref, "" if in the input schema
+	pointer string // location of the $ref within that schema
+	err     error
+}
+
+func (e *derefError) Error() string {
+	if e.schema != "" {
+		return fmt.Sprintf("in schema %s at %s: %v", e.schema, e.pointer, e.err)
+	}
+	return fmt.Sprintf("at %s: %v", e.pointer, e.err)
+}
+
+func (e *derefError) Unwrap() error { return e.err }
+
+// withPointerPrefix prepends prefix to the location of a derefError within the
+// input schema. This is used when the input schema is nested within a larger
+// definition.
+func withPointerPrefix(prefix string, err error) error {
+	var de *derefError
+	if !errors.As(err, &de) || de.schema != "" {
+		return err
+	}
+	return &derefError{pointer: prefix + de.pointer, err: de.err}
+}
+
 // dereferencer holds the state needed to recursively expand $ref entries.
 type dereferencer struct {
 	repository schemaRepository
 	visiting   map[string]bool
+
+	// schema and path track the location being expanded, for error reporting.
+	schema string
+	path   []string
+}
+
+func (d *dereferencer) errorf(format string, args ...any) error {
+	pointer := ""
+	for _, token := range d.path {
+		pointer += "/" + escapePointer(token)
+	}
+	return &derefError{schema: d.schema, pointer: pointer + "/$ref", err: fmt.Errorf(format, args...)}
 }
 
 func (d *dereferencer) value(v any) (any, error) {
@@ -42,9 +83,11 @@ func (d *dereferencer) value(v any) (any, error) {
 func (d *dereferencer) slice(arr []any) ([]any, error) {
 	out := make([]any, len(arr))
 	for i, item := range arr {
+		d.path = append(d.path, strconv.Itoa(i))
 		expanded, err := d.value(item)
+		d.path = d.path[:len(d.path)-1]
 		if err != nil {
-			return nil, fmt.Errorf("[%d]: %w", i, err)
+			return nil, err
 		}
 		out[i] = expanded
 	}
@@ -65,9 +108,11 @@ func (d *dereferencer) object(obj object) (object, error) {
 		if k == "$ref" {
 			continue
 		}
+		d.path = append(d.path, k)
 		expanded, err := d.value(v)
+		d.path = d.path[:len(d.path)-1]
 		if err != nil {
-			return nil, fmt.Errorf(".%s%w", k, err)
+			return nil, err
 		}
 		out[k] = expanded
 	}
@@ -92,31 +137,44 @@ func (d *dereferencer) object(obj object) (object, error) {
 func (d *dereferencer) resolveRef(ref string) (object, error) {
 	name, pointer, err := parseSchemaRef(ref)
 	if err != nil {
-		return nil, err
+		return nil, d.errorf("%v", err)
 	}
 
 	// Use name-only key for cycle detection since sub-path refs are not cyclic
 	// by themselves — only whole-schema references can form cycles.
 	cycleKey := name
 	if d.visiting[cycleKey] {
-		return nil, fmt.Errorf("$ref %q: cycle detected", ref)
+		return nil, d.errorf("$ref %q: cycle detected", ref)
 	}
 	schema, ok := d.repository[name]
 	if !ok {
-		return nil, fmt.Errorf("$ref %q: schema not found in repository", ref)
+		return nil, d.errorf("$ref %q: schema not found in repository", ref)
 	}
 
 	// Navigate into sub-path if present.
+	target := schema
 	if pointer != "" {
 		val, err := jsonpointer.Get(schema, pointer)
 		if err != nil {
-			return nil, fmt.Errorf("$ref %q: %w", ref, err)
+			return nil, d.errorf("$ref %q: %w", ref, err)
 		}
 		node, ok := val.(object)
 		if !ok {
-			return nil, fmt.Errorf("$ref %q: pointed-to value at %q is not an object", ref, pointer)
+			return nil, d.errorf("$ref %q: pointed-to value at %q is not an object", ref, pointer)
+		}
+		target = node
+	}
+
+	// Continue in the referenced schema. Errors found within it are reported
+	// at their location in that schema.
+	savedSchema, savedPath := d.schema, d.path
+	defer func() { d.schema, d.path = savedSchema, savedPath }()
+	d.schema, d.path = name, nil
+	if pointer != "" {
+		for _, token := range strings.Split(pointer[1:], "/") {
+			d.path = append(d.path, unescapePointer(token))
 		}
-		return d.object(node)
+		return d.object(target)
 	}
 
 	d.visiting[cycleKey] = true
@@ -2,6 +2,7 @@ package specgen
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -14,9 +15,10 @@ type errorGroups []errorGroup
 
 // errorGroup defines a single error group
 type errorGroup struct {
-	Name   string     `yaml:"group"`
-	Range  groupRange `yaml:"range"`
-	Errors []Error    `yaml:"errors"`
+	Name    string     `yaml:"group"`
+	Range   groupRange `yaml:"range"`
+	Extends []string   `yaml:"extends"`
+	Errors  []Error    `yaml:"errors"`
 }
 
 type groupRange struct {
@@ -40,50 +42,136 @@ func (groups errorGroups) find(name string) (errorGroup, bool) {
 	return errorGroup{}, false
 }
 
+// errorOverride is an inline error of a method which replaces a group error
+// with the same code but a different message.
+type errorOverride struct {
+	index   int    // index of the inline error
+	group   string // name of the referenced group
+	message string // message of the group error
+}
+
+// groupErrors returns the errors of the named group, followed by the errors it
+// inherits from the groups it extends. The group's own errors take precedence
+// over inherited errors with the same code, and of the extended groups, the one
+// listed first takes precedence.
+func (groups errorGroups) groupErrors(name string, extending []string) ([]Error, error) {
+	if i := slices.Index(extending, name); i >= 0 {
+		cycle := append(slices.Clone(extending[i:]), name)
+		return nil, fmt.Errorf("error group %s extends itself: %s", name, strings.Join(cycle, " -> "))
+	}
+	group, ok := groups.find(name)
+	if !ok {
+		return nil, fmt.Errorf("error group %s not found", name)
+	}
+	errs := slices.Clone(group.Errors)
+	codes := make(map[int]bool)
+	for _, e := range errs {
+		codes[e.Code] = true
+	}
+	for _, parent := range group.Extends {
+		inherited, err := groups.groupErrors(parent, append(extending, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range inherited {
+			if !codes[e.Code] {
+				codes[e.Code] = true
+				errs = append(errs, e)
+			}
+		}
+	}
+	return errs, nil
+}
+
 // resolveMethodErrors merges a method's existing errors with resolved error-group refs.
-// IMPORTANT: Inline errors take precedence: group errors with a matching code are skipped
-func (groups errorGroups) resolveMethodErrors(existingErrors []any, errorGroupRefs []any) ([]any, error) {
+// IMPORTANT: Inline errors take precedence: group errors with a matching code are skipped.
+// The inline errors which override a group error with a different message are returned
+// as overrides. A ref may exclude errors of the group by code, using a list of codes
+// in its "exclude" member.
+func (groups errorGroups) resolveMethodErrors(existingErrors []any, errorGroupRefs []any) ([]any, []errorOverride, error) {
 	var merged []any
 	merged = append(merged, existingErrors...)
 
 	// Collect inline error codes so group errors don't override them.
-	inlineCodes := make(map[int]bool)
-	for _, e := range existingErrors {
+	inlineCodes := make(map[int]int)
+	for i, e := range existingErrors {
 		if obj, ok := e.(object); ok {
 			if code, ok := obj["code"].(int); ok {
-				inlineCodes[code] = true
+				inlineCodes[code] = i
 			}
 		}
 	}
 
+	var overrides []errorOverride
 	for i, ref := range errorGroupRefs {
 		obj, ok := ref.(object)
 		if !ok {
-			return nil, fmt.Errorf("failed to resolve error-groups[%d]: expected object with $ref, got %T", i, ref)
+			return nil, nil, fmt.Errorf("failed to resolve error-groups[%d]: expected object with $ref, got %T", i, ref)
 		}
 		ref, isRef := obj["$ref"].(string)
 		if !isRef {
-			return nil, fmt.Errorf("failed to resolve error-groups[%d]: expected $ref string, got %v", i, obj)
+			return nil, nil, fmt.Errorf("failed to resolve error-groups[%d]: expected $ref string, got %v", i, obj)
 		}
 		name, err := parseErrorGroupRef(ref)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve error-groups[%d]: %w", i, err)
+			return nil, nil, fmt.Errorf("failed to resolve error-groups[%d]: %w", i, err)
 		}
 		group, ok := groups.find(name)
 		if !ok {
-			return nil, fmt.Errorf("failed to resolve error-groups[%d]: $ref %q not found", i, ref)
+			return nil, nil, fmt.Errorf("failed to resolve error-groups[%d]: $ref %q not found", i, ref)
 		}
 		for j, e := range group.Errors {
 			if err := group.validateErrorCode(e); err != nil {
-				return nil, fmt.Errorf("failed to validate error-groups[%d]: group %s: error[%d]: %w", i, name, j, err)
+				return nil, nil, fmt.Errorf("failed to validate error-groups[%d]: group %s: error[%d]: %w", i, name, j, err)
 			}
-			if inlineCodes[e.Code] {
+		}
+		errs, err := groups.groupErrors(name, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve error-groups[%d]: %w", i, err)
+		}
+		excluded, err := parseExclude(obj["exclude"], errs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve error-groups[%d]: group %s: %w", i, name, err)
+		}
+		for _, e := range errs {
+			if excluded[e.Code] {
+				continue
+			}
+			if j, ok := inlineCodes[e.Code]; ok {
+				inline := existingErrors[j].(object)
+				if inline["message"] != e.Message {
+					overrides = append(overrides, errorOverride{index: j, group: name, message: e.Message})
+				}
 				continue
 			}
 			merged = append(merged, e.toObject())
 		}
 	}
-	return merged, nil
+	return merged, overrides, nil
+}
+
+// parseExclude parses the codes excluded by an error group ref. Each must be the
+// code of one of the group's errors.
+func parseExclude(v any, errs []Error) (map[int]bool, error) {
+	if v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("exclude: expected list of error codes, got %T", v)
+	}
+	excluded := make(map[int]bool, len(list))
+	for _, item := range list {
+		code, ok := item.(int)
+		if !ok {
+			return nil, fmt.Errorf("exclude: expected error code, got %v", item)
+		}
+		if !slices.ContainsFunc(errs, func(e Error) bool { return e.Code == code }) {
+			return nil, fmt.Errorf("exclude: no error with code %d", code)
+		}
+		excluded[code] = true
+	}
+	return excluded, nil
 }
 
 // validateErrorCode checks that the error code falls within the group's range.
@@ -132,7 +220,7 @@ func (e Error) toObject() object {
 func parseErrorGroups(content []byte) (errorGroups, error) {
 	var body map[string]errorGroup
 	if err := yaml.Unmarshal(content, &body); err != nil {
-		return nil, fmt.Errorf("failed to parse error group content: %v", err)
+		return nil, fmt.Errorf("failed to parse error group content: %w", err)
 	}
 	var groups errorGroups
 	for name, group := range body {
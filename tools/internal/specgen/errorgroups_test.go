@@ -17,8 +17,9 @@ func TestResolveMethodErrors(t *testing.T) {
 		groups         errorGroups
 		existingErrors []any
 		refs           []any
-		wantCodes      []int  // expected codes in order; nil when expecting error
-		wantErr        string // substring expected in error
+		wantCodes      []int    // expected codes in order; nil when expecting error
+		wantMessages   []string // expected messages in order, if set
+		wantErr        string   // substring expected in error
 	}{
 		{
 			name: "single group ref",
@@ -62,6 +63,42 @@ func TestResolveMethodErrors(t *testing.T) {
 			refs:           []any{ref("ExecutionErrors")},
 			wantCodes:      []int{3},
 		},
+		{
+			name: "extends",
+			groups: errorGroups{
+				{Name: "A", Errors: []Error{{Code: 1, Message: "a"}, {Code: 2, Message: "a"}}},
+				{Name: "B", Errors: []Error{{Code: 2, Message: "b"}, {Code: 3, Message: "b"}}},
+				{Name: "C", Extends: []string{"A", "B"}, Errors: []Error{{Code: 3, Message: "c"}}},
+			},
+			refs:         []any{ref("C")},
+			wantCodes:    []int{3, 1, 2},
+			wantMessages: []string{"c", "a", "a"},
+		},
+		{
+			name: "exclude",
+			groups: errorGroups{
+				{Name: "A", Errors: []Error{{Code: 1, Message: "a"}, {Code: 2, Message: "b"}, {Code: 3, Message: "c"}}},
+			},
+			refs:      []any{object{"$ref": errorGroupRefPrefix + "A", "exclude": []any{1, 3}}},
+			wantCodes: []int{2},
+		},
+		{
+			name: "exclude unknown code",
+			groups: errorGroups{
+				{Name: "A", Errors: []Error{{Code: 1, Message: "a"}}},
+			},
+			refs:    []any{object{"$ref": errorGroupRefPrefix + "A", "exclude": []any{2}}},
+			wantErr: "no error with code 2",
+		},
+		{
+			name: "extends cycle",
+			groups: errorGroups{
+				{Name: "A", Extends: []string{"B"}},
+				{Name: "B", Extends: []string{"A"}},
+			},
+			refs:    []any{ref("A")},
+			wantErr: "A -> B -> A",
+		},
 		{
 			name: "out of range code in group",
 			groups: errorGroups{
@@ -93,7 +130,7 @@ func TestResolveMethodErrors(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.groups.resolveMethodErrors(tt.existingErrors, tt.refs)
+			got, _, err := tt.groups.resolveMethodErrors(tt.existingErrors, tt.refs)
 			if tt.wantErr != "" {
 				if err == nil {
 					t.Fatal("expected error")
@@ -114,10 +151,86 @@ func TestResolveMethodErrors(t *testing.T) {
 					t.Errorf("error[%d] code: want %d, got %v", i, code, got[i].(object)["code"])
 				}
 			}
+			for i, msg := range tt.wantMessages {
+				if got[i].(object)["message"] != msg {
+					t.Errorf("error[%d] message: want %s, got %v", i, msg, got[i].(object)["message"])
+				}
+			}
 		})
 	}
 }
 
+const overrideGroups = `
+BaseErrors:
+  errors:
+    - code: 1
+      message: "Nonce too low"
+    - code: 2
+      message: "Nonce too high"
+SubmitErrors:
+  extends:
+    - BaseErrors
+  errors:
+    - code: 3
+      message: "Execution reverted"
+`
+
+const overrideMethods = `
+- name: test_submit
+  params: []
+  errors:
+    - code: 1
+      message: "Nonce too low"
+    - code: 3
+      message: "Reverted"
+  error-groups:
+    - $ref: '#/components/error-groups/SubmitErrors'
+      exclude: [2]
+  result:
+    name: Nothing
+    schema:
+      type: 'null'
+`
+
+func TestResolveErrorGroups(t *testing.T) {
+	g := New()
+	if err := g.AddErrorGroups("groups.yaml", []byte(overrideGroups)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(overrideMethods)); err != nil {
+		t.Fatal(err)
+	}
+	warnings, err := g.ResolveErrorGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `methods.yaml:7:7: method test_submit: error 3 "Reverted" overrides "Execution reverted" of error group SubmitErrors`
+	if len(warnings) != 1 || warnings[0].Error() != want {
+		t.Fatalf("wrong warnings %v, want %s", warnings, want)
+	}
+	if errs := g.methods["test_submit"]["errors"].([]any); len(errs) != 2 {
+		t.Errorf("want 2 errors, got %v", errs)
+	}
+}
+
+func TestResolveErrorGroupsExtendsUnknown(t *testing.T) {
+	groups := overrideGroups + `
+OtherErrors:
+  extends:
+    - SubmitErrors
+    - MissingErrors
+`
+	g := New()
+	if err := g.AddErrorGroups("groups.yaml", []byte(groups)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := g.ResolveErrorGroups()
+	want := "groups.yaml:18:7: error group OtherErrors extends unknown group MissingErrors"
+	if err == nil || err.Error() != want {
+		t.Fatalf("wrong error %v, want %s", err, want)
+	}
+}
+
 func TestValidateErrorCode(t *testing.T) {
 	tests := []struct {
 		name    string
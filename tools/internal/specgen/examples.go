@@ -0,0 +1,111 @@
+package specgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// docURL is the location the built document is compiled from when validating
+// examples. Schema references within the document resolve against it.
+const docURL = "file:///openrpc.json"
+
+// validateExamples checks that the param and result values of each method
+// example validate against the param and result schemas of the method.
+//
+// All invalid examples are reported. Example params are matched to the method
+// params by position, as required by OpenRPC, so examples may leave out trailing
+// optional params.
+func validateExamples(doc object) error {
+	// Round-trip the document through JSON, so values decoded from YAML are
+	// represented the way the validator expects.
+	enc, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	parsed, err := jsonschema.UnmarshalJSON(bytes.NewReader(enc))
+	if err != nil {
+		return err
+	}
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2019)
+	if err := c.AddResource(docURL, parsed); err != nil {
+		return err
+	}
+	compile := func(ptr string) (*jsonschema.Schema, error) {
+		return c.Compile(docURL + "#" + ptr)
+	}
+
+	var errs []error
+	methods, _ := parsed.(object)["methods"].([]any)
+	for i, m := range methods {
+		method, _ := m.(object)
+		name, _ := method["name"].(string)
+		examples, _ := method["examples"].([]any)
+		params, _ := method["params"].([]any)
+		for j, e := range examples {
+			example, ok := e.(object)
+			if !ok {
+				continue
+			}
+			exampleName, _ := example["name"].(string)
+			if exampleName == "" {
+				exampleName = fmt.Sprint(j)
+			}
+			exampleParams, _ := example["params"].([]any)
+			if len(exampleParams) > len(params) {
+				errs = append(errs, &docError{
+					pointer: fmt.Sprintf("/methods/%d/examples/%d/params", i, j),
+					err:     fmt.Errorf("method %s, example %q: has %d params, but method takes %d", name, exampleName, len(exampleParams), len(params)),
+				})
+				continue
+			}
+			for k, p := range exampleParams {
+				value, ok := exampleValue(p)
+				if !ok {
+					continue
+				}
+				paramName, _ := params[k].(object)["name"].(string)
+				schema, err := compile(fmt.Sprintf("/methods/%d/params/%d/schema", i, k))
+				if err != nil {
+					return fmt.Errorf("method %s, param %s: %v", name, paramName, err)
+				}
+				if err := schema.Validate(value); err != nil {
+					errs = append(errs, &docError{
+						pointer: fmt.Sprintf("/methods/%d/examples/%d/params/%d", i, j, k),
+						err:     fmt.Errorf("method %s, example %q, param %s: %v", name, exampleName, paramName, err),
+					})
+				}
+			}
+			value, ok := exampleValue(example["result"])
+			if !ok {
+				continue
+			}
+			schema, err := compile(fmt.Sprintf("/methods/%d/result/schema", i))
+			if err != nil {
+				return fmt.Errorf("method %s, result: %v", name, err)
+			}
+			if err := schema.Validate(value); err != nil {
+				errs = append(errs, &docError{
+					pointer: fmt.Sprintf("/methods/%d/examples/%d/result", i, j),
+					err:     fmt.Errorf("method %s, example %q, result: %v", name, exampleName, err),
+				})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// exampleValue returns the value of an OpenRPC example object. Examples
+// without an embedded value, e.g. those using externalValue, are skipped.
+func exampleValue(e any) (any, bool) {
+	example, ok := e.(object)
+	if !ok {
+		return nil, false
+	}
+	value, ok := example["value"]
+	return value, ok
+}
@@ -0,0 +1,127 @@
+package specgen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const exampleSchemas = `
+uint:
+  type: string
+  pattern: ^0x(0|[1-9a-f][0-9a-f]*)$
+`
+
+const exampleMethod = `
+- name: test_double
+  params:
+    - name: Value
+      required: true
+      schema:
+        $ref: '#/components/schemas/uint'
+    - name: Extra
+      required: false
+      schema:
+        type: boolean
+  result:
+    name: Doubled
+    schema:
+      $ref: '#/components/schemas/uint'
+  examples:
+    - name: test_double example
+      params:
+        - name: Value
+          value: %s
+      result:
+        name: Doubled
+        value: %s
+`
+
+func TestValidateExamples(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   string
+		result  string
+		wantErr []string // substrings expected in error; nil when valid
+	}{
+		{
+			name:   "valid",
+			param:  "'0x1'",
+			result: "'0x2'",
+		},
+		{
+			name:    "invalid param",
+			param:   "'0x01'",
+			result:  "'0x2'",
+			wantErr: []string{"method test_double", `example "test_double example"`, "param Value"},
+		},
+		{
+			name:    "invalid result",
+			param:   "'0x1'",
+			result:  "2",
+			wantErr: []string{"method test_double", `example "test_double example"`, "result"},
+		},
+	}
+	for _, tt := range tests {
+		for _, deref := range []bool{false, true} {
+			g := New()
+			if err := g.AddSchemas("schemas.yaml", []byte(exampleSchemas)); err != nil {
+				t.Fatal(err)
+			}
+			if err := g.AddMethods("methods.yaml", []byte(fmt.Sprintf(exampleMethod, tt.param, tt.result))); err != nil {
+				t.Fatal(err)
+			}
+			if deref {
+				if err := g.Dereference(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := g.Validate()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("%s (deref=%v): unexpected error: %v", tt.name, deref, err)
+				}
+				continue
+			}
+			if err == nil {
+				t.Errorf("%s (deref=%v): expected error", tt.name, deref)
+				continue
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("%s (deref=%v): error should mention %q, got: %v", tt.name, deref, want, err)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateExamples_TooManyParams(t *testing.T) {
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(exampleSchemas)); err != nil {
+		t.Fatal(err)
+	}
+	method := `
+- name: test_none
+  params: []
+  result:
+    name: Nothing
+    schema:
+      type: 'null'
+  examples:
+    - name: test_none example
+      params:
+        - name: Value
+          value: '0x1'
+      result:
+        name: Nothing
+        value: null
+`
+	if err := g.AddMethods("methods.yaml", []byte(method)); err != nil {
+		t.Fatal(err)
+	}
+	err := g.Validate()
+	if err == nil || !strings.Contains(err.Error(), "has 1 params, but method takes 0") {
+		t.Fatalf("expected param count error, got: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package specgen
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// forks lists the forks of the execution layer in activation order. Fork
+// annotations and the Engine API fork specifications use these names.
+var forks = []string{
+	"frontier", "homestead", "tangerineWhistle", "spuriousDragon", "byzantium",
+	"constantinople", "petersburg", "istanbul", "muirGlacier", "berlin",
+	"london", "arrowGlacier", "grayGlacier", "paris", "shanghai", "cancun",
+	"prague", "osaka", "amsterdam", "bogota",
+}
+
+// The fork annotations. Methods, params and schemas, including schema
+// properties, can carry them.
+const (
+	sinceForkKey  = "x-since-fork" // fork which introduced the definition
+	deprecatedKey = "x-deprecated" // fork from which it is deprecated
+)
+
+// versionedMethod matches the names of versioned methods, e.g.
+// engine_newPayloadV4.
+var versionedMethod = regexp.MustCompile(`^(.+)V([0-9]+)$`)
+
+// forkAnnotation is a definition annotated with forks.
+type forkAnnotation struct {
+	pointer           string // location of the annotated object
+	since, deprecated int    // indexes in forks, -1 if not set
+	err               error  // set if the annotations are invalid
+}
+
+// collectForkAnnotations appends the fork annotations within v to list.
+func collectForkAnnotations(v any, pointer string, list *[]forkAnnotation) {
+	switch val := v.(type) {
+	case object:
+		_, hasSince := val[sinceForkKey]
+		_, hasDeprecated := val[deprecatedKey]
+		if hasSince || hasDeprecated {
+			*list = append(*list, parseForkAnnotation(val, pointer))
+		}
+		for _, k := range slices.Sorted(maps.Keys(val)) {
+			if k != "examples" {
+				collectForkAnnotations(val[k], pointer+"/"+escapePointer(k), list)
+			}
+		}
+	case []any:
+		for i, item := range val {
+			collectForkAnnotations(item, pointer+"/"+strconv.Itoa(i), list)
+		}
+	}
+}
+
+func parseForkAnnotation(obj object, pointer string) forkAnnotation {
+	a := forkAnnotation{pointer: pointer, since: -1, deprecated: -1}
+	for _, key := range []string{sinceForkKey, deprecatedKey} {
+		v, ok := obj[key]
+		if !ok {
+			continue
+		}
+		fork, _ := v.(string)
+		i := slices.Index(forks, fork)
+		if i < 0 {
+			a.pointer = pointer + "/" + key
+			a.err = fmt.Errorf("%s: unknown fork %v, must be one of %s", key, v, strings.Join(forks, ", "))
+			return a
+		}
+		if key == sinceForkKey {
+			a.since = i
+		} else {
+			a.deprecated = i
+		}
+	}
+	if a.since >= 0 && a.deprecated >= 0 && a.deprecated <= a.since {
+		a.pointer = pointer + "/" + deprecatedKey
+		a.err = fmt.Errorf("%s: deprecated in %s, but introduced in %s", deprecatedKey, forks[a.deprecated], forks[a.since])
+	}
+	return a
+}
+
+// docsFork returns the index in forks of the Engine API fork specification
+// the externalDocs of a method link to, e.g. src/engine/prague.md, or -1.
+func docsFork(method object) int {
+	docs, _ := method["externalDocs"].(object)
+	url, _ := docs["url"].(string)
+	url, _, _ = strings.Cut(url, "#")
+	if !strings.Contains(url, "/src/engine/") {
+		return -1
+	}
+	return slices.Index(forks, strings.TrimSuffix(path.Base(url), ".md"))
+}
+
+// methodFork returns the index in forks of the fork which introduced method,
+// or -1 if it isn't tied to a fork.
+//
+// The fork is given by the method's x-since-fork annotation, or else taken
+// from its externalDocs, which link to the section of the fork specification
+// defining the method.
+func methodFork(method object) int {
+	if fork, ok := method[sinceForkKey].(string); ok {
+		return slices.Index(forks, fork)
+	}
+	return docsFork(method)
+}
+
+// CheckForks checks the fork annotations of the methods and schemas. Fork
+// names must be known, a definition can't be deprecated before it was
+// introduced, and the x-since-fork of a method must agree with the fork
+// specification its externalDocs link to.
+//
+// The versions of a method, like engine_newPayloadV1 to engine_newPayloadV4,
+// must be introduced in fork order: a later version can't be tied to an
+// earlier fork than a previous version.
+func (s *Generator) CheckForks() error {
+	var errs []error
+	check := func(kind, name string, def object) {
+		var list []forkAnnotation
+		collectForkAnnotations(def, "", &list)
+		for _, a := range list {
+			if a.err != nil {
+				errs = append(errs, s.errorAt(kind+"s/"+name, a.pointer, fmt.Errorf("%s %s: %w", kind, name, a.err)))
+			}
+		}
+	}
+
+	families := make(map[string][]string)
+	for _, name := range slices.Sorted(maps.Keys(s.methods)) {
+		method := s.methods[name]
+		check("method", name, method)
+		fork, _ := method[sinceForkKey].(string)
+		since := slices.Index(forks, fork)
+		if docs := docsFork(method); since >= 0 && docs >= 0 && since != docs {
+			err := fmt.Errorf("method %s: %s %s, but externalDocs link to the %s specification", name, sinceForkKey, forks[since], forks[docs])
+			errs = append(errs, s.errorAt("methods/"+name, "/"+sinceForkKey, err))
+		}
+		if m := versionedMethod.FindStringSubmatch(name); m != nil {
+			families[m[1]] = append(families[m[1]], name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.types)) {
+		check("schema", name, s.types[name])
+	}
+
+	for _, family := range slices.Sorted(maps.Keys(families)) {
+		versions := families[family]
+		slices.SortFunc(versions, func(a, b string) int { return methodVersion(a) - methodVersion(b) })
+		latest := ""
+		for _, name := range versions {
+			fork := methodFork(s.methods[name])
+			if fork < 0 {
+				continue
+			}
+			if latest != "" && fork < methodFork(s.methods[latest]) {
+				err := fmt.Errorf("method %s: introduced in %s, before %s which was introduced in %s", name, forks[fork], latest, forks[methodFork(s.methods[latest])])
+				errs = append(errs, s.errorAt("methods/"+name, "", err))
+				continue
+			}
+			latest = name
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// methodVersion returns the version of a versioned method.
+func methodVersion(name string) int {
+	m := versionedMethod.FindStringSubmatch(name)
+	v, _ := strconv.Atoi(m[2])
+	return v
+}
+
+// forkNote returns sentences describing the fork annotations of a definition
+// for documentation, e.g. "Since cancun.", or "" for those it doesn't have.
+func forkNote(def object) (since, deprecated string) {
+	if fork, ok := def[sinceForkKey].(string); ok {
+		since = "Since " + fork + "."
+	}
+	if fork, ok := def[deprecatedKey].(string); ok {
+		deprecated = "Deprecated since " + fork + "."
+	}
+	return since, deprecated
+}
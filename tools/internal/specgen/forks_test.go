@@ -0,0 +1,121 @@
+package specgen
+
+import (
+	"strings"
+	"testing"
+)
+
+const forksSchemas = `
+Header:
+  type: object
+  properties:
+    number:
+      type: string
+    blobGasUsed:
+      type: string
+      x-since-fork: cancun
+    difficulty:
+      type: string
+      x-deprecated: paris
+`
+
+const forksMethods = `
+- name: engine_testV1
+  externalDocs:
+    url: https://github.com/ethereum/execution-apis/blob/main/src/engine/paris.md#engine_testv1
+  params: []
+  result:
+    name: Header
+    schema:
+      $ref: '#/components/schemas/Header'
+- name: engine_testV2
+  x-since-fork: shanghai
+  params:
+    - name: Extra
+      x-since-fork: cancun
+      schema:
+        type: string
+  result:
+    name: Header
+    schema:
+      $ref: '#/components/schemas/Header'
+`
+
+func TestCheckForks(t *testing.T) {
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(forksSchemas)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(forksMethods)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.CheckForks(); err != nil {
+		t.Fatal(err)
+	}
+	// The annotations are allowed by the OpenRPC meta-schema.
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := methodFork(g.methods["engine_testV2"]); forks[got] != "shanghai" {
+		t.Errorf("wrong fork of engine_testV2: %d", got)
+	}
+}
+
+func TestCheckForksErrors(t *testing.T) {
+	schemas := forksSchemas + `
+Bad:
+  type: object
+  properties:
+    a:
+      type: string
+      x-since-fork: atlantis
+    b:
+      type: string
+      x-since-fork: prague
+      x-deprecated: cancun
+`
+	methods := forksMethods + `
+- name: engine_testV3
+  x-since-fork: paris
+  params: []
+  result:
+    name: Nothing
+    schema:
+      type: 'null'
+- name: engine_other
+  x-since-fork: cancun
+  externalDocs:
+    url: https://github.com/ethereum/execution-apis/blob/main/src/engine/prague.md#engine_other
+  params: []
+  result:
+    name: Nothing
+    schema:
+      type: 'null'
+`
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(schemas)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(methods)); err != nil {
+		t.Fatal(err)
+	}
+	err := g.CheckForks()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	want := []string{
+		"methods.yaml:30:3: method engine_other: x-since-fork cancun, but externalDocs link to the prague specification",
+		"schemas.yaml:19:7: schema Bad: x-since-fork: unknown fork atlantis, must be one of " + strings.Join(forks, ", "),
+		"schemas.yaml:23:7: schema Bad: x-deprecated: deprecated in cancun, but introduced in prague",
+		"methods.yaml:22:3: method engine_testV3: introduced in paris, before engine_testV2 which was introduced in shanghai",
+	}
+	got := strings.Split(err.Error(), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of errors, got:\n%v", err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("error %d:\ngot  %s\nwant %s", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,776 @@
+package specgen
+
+import (
+	"fmt"
+	"go/format"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// goInitialisms are the words written in upper case in Go names.
+var goInitialisms = map[string]bool{
+	"api": true, "evm": true, "http": true, "id": true, "json": true,
+	"kzg": true, "rlp": true, "rpc": true, "uri": true, "url": true,
+}
+
+// goReserved are the identifiers generated params must not shadow.
+var goReserved = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+	// Names used by the generated code.
+	"c": true, "ctx": true, "args": true, "result": true, "err": true,
+	"common": true, "context": true, "fmt": true, "hexutil": true, "json": true,
+	"rpc": true, "slices": true,
+}
+
+var (
+	// hexQuantityPattern matches the patterns of hex encoded integers, e.g.
+	// ^0x(0|[1-9a-f][0-9a-f]{0,15})$, capturing the maximum number of digits.
+	hexQuantityPattern = regexp.MustCompile(`^\^0x\(0\|\[1-9a-f\]\[0-9a-f\](?:\*|\{0,(\d+)\})\)\$$`)
+	// hexBytesPattern matches the patterns of hex encoded byte strings, e.g.
+	// ^0x[0-9a-f]{64}$, capturing the exact number of digits if fixed.
+	hexBytesPattern = regexp.MustCompile(`^\^0x\[0-9a-f(?:A-F)?\](?:\*|\{(\d+)\}|\{\d+,\d+\})\$$`)
+)
+
+// goGenerator generates Go bindings.
+type goGenerator struct {
+	s        *Generator
+	decls    []string          // type declarations
+	types    map[string]string // Go type of each $ref
+	names    map[string]bool   // declared type names
+	building map[string]bool   // $refs whose type is being created, and whether they're recursive
+}
+
+// Go generates Go bindings for the spec in package pkg. The bindings have a
+// type for each component schema and a Client with a method for each method,
+// using go-ethereum's rpc.Client.
+//
+// Hex encoded quantities and byte strings map to hexutil types, addresses and
+// hashes to common.Address and common.Hash. A oneOf or anyOf of several types
+// maps to a struct with a field for each alternative, of which at most one is
+// set. When decoding, the first alternative the value decodes as is set;
+// object alternatives are told apart by their required fields and fields with
+// fixed values, like the transaction type.
+//
+// Go must be called before Dereference.
+func (s *Generator) Go(pkg string) ([]byte, error) {
+	g := &goGenerator{
+		s:        s,
+		types:    make(map[string]string),
+		names:    make(map[string]bool),
+		building: make(map[string]bool),
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.types)) {
+		if _, err := g.refType("#/components/schemas/" + escapePointer(name)); err != nil {
+			return nil, s.errorAt("schemas/"+name, "", fmt.Errorf("schema %s: %w", name, err))
+		}
+	}
+	var methods []string
+	for _, name := range slices.Sorted(maps.Keys(s.methods)) {
+		m, err := g.method(name, s.methods[name])
+		if err != nil {
+			return nil, s.errorAt("methods/"+name, "", fmt.Errorf("method %s: %w", name, err))
+		}
+		methods = append(methods, m)
+	}
+
+	var body strings.Builder
+	for _, decl := range g.decls {
+		body.WriteString(decl + "\n")
+	}
+	body.WriteString(goClient)
+	for _, m := range methods {
+		body.WriteString(m + "\n")
+	}
+	if strings.Contains(body.String(), "shape{") {
+		body.WriteString(goShape)
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "// Code generated by specgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	thirdParty := false
+	for _, imp := range []struct{ path, use string }{
+		{"context", "context."},
+		{"encoding/json", "json."},
+		{"fmt", "fmt."},
+		{"slices", "slices."},
+		{"github.com/ethereum/go-ethereum/common", "common."},
+		{"github.com/ethereum/go-ethereum/common/hexutil", "hexutil."},
+		{"github.com/ethereum/go-ethereum/rpc", "rpc."},
+	} {
+		if !strings.Contains(body.String(), imp.use) {
+			continue
+		}
+		if strings.Contains(imp.path, ".") && !thirdParty {
+			// Separate third party imports from the standard library.
+			out.WriteString("\n")
+			thirdParty = true
+		}
+		fmt.Fprintf(&out, "\t%q\n", imp.path)
+	}
+	out.WriteString(")\n\n")
+	out.WriteString(body.String())
+	return format.Source([]byte(out.String()))
+}
+
+const goClient = `// Client calls the methods of the spec.
+type Client struct {
+	c *rpc.Client
+}
+
+// NewClient creates a client which sends its requests using c.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{c: c}
+}
+
+`
+
+const goShape = `
+// shape describes the objects accepted by an object alternative of a oneOf
+// or anyOf.
+type shape struct {
+	required  []string
+	forbidden []string
+	values    map[string][]any // allowed values of fields with fixed values
+	fields    []string         // if set, the only fields allowed
+}
+
+func (s shape) matches(data []byte) bool {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil || obj == nil {
+		return false
+	}
+	for _, f := range s.required {
+		if _, ok := obj[f]; !ok {
+			return false
+		}
+	}
+	for _, f := range s.forbidden {
+		if _, ok := obj[f]; ok {
+			return false
+		}
+	}
+	for f, allowed := range s.values {
+		raw, ok := obj[f]
+		if !ok {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil || !slices.Contains(allowed, v) {
+			return false
+		}
+	}
+	if s.fields != nil {
+		for f := range obj {
+			if !slices.Contains(s.fields, f) {
+				return false
+			}
+		}
+	}
+	return true
+}
+`
+
+// goName returns the exported Go name for a name from the spec.
+func goName(name string) string {
+	n := pascalCase(nameWords(name), goInitialisms)
+	if n == "" || (n[0] >= '0' && n[0] <= '9') {
+		n = "X" + n
+	}
+	return n
+}
+
+// declare reserves a unique type name based on name.
+func (g *goGenerator) declare(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+// refType returns the Go type of the schema referenced by ref.
+func (g *goGenerator) refType(ref string) (string, error) {
+	if t, ok := g.types[ref]; ok {
+		if _, ok := g.building[ref]; ok {
+			g.building[ref] = true
+		}
+		return t, nil
+	}
+	schema, err := g.s.lookupRef(ref)
+	if err != nil {
+		return "", err
+	}
+	component, path, _ := parseSchemaRef(ref)
+	name := goName(component)
+	for _, token := range strings.Split(path, "/") {
+		if token != "" && token != "properties" {
+			name += goName(unescapePointer(token))
+		}
+	}
+	if enum, ok := stringEnum(schema); ok && path == "" {
+		t := g.enum(name, schema, enum)
+		g.types[ref] = t
+		return t, nil
+	}
+	// While the type is being created, references to it use its name. If it
+	// turns out not to need a declaration, e.g. an array of itself, it is
+	// declared as a defined type.
+	g.types[ref] = name
+	g.building[ref] = false
+	t, err := g.typeOf(schema, name)
+	if err != nil {
+		return "", err
+	}
+	if recursive := g.building[ref]; recursive && t != name {
+		name = g.declare(name)
+		g.decls = append(g.decls, fmt.Sprintf("type %s %s\n", name, t))
+		t = name
+	}
+	delete(g.building, ref)
+	g.types[ref] = t
+	return t, nil
+}
+
+// typeOf returns the Go type of values of the given schema. Types which need
+// a declaration, like structs, are declared with a name based on name.
+func (g *goGenerator) typeOf(v any, name string) (string, error) {
+	schema, ok := v.(object)
+	if !ok || len(schema) == 0 {
+		return "json.RawMessage", nil
+	}
+	if ref, ok := plainRef(schema); ok {
+		return g.refType(ref)
+	}
+	if _, ok := schema["$ref"]; ok {
+		expanded, err := g.s.expandRef(schema)
+		if err != nil {
+			return "", err
+		}
+		return g.typeOf(expanded, name)
+	}
+	if _, ok := schema["allOf"]; ok {
+		flat, err := g.s.flattenAllOf(schema)
+		if err != nil {
+			return "", err
+		}
+		return g.typeOf(flat, name)
+	}
+	if alts := alternatives(schema); alts != nil {
+		return g.choice(schema, alts, name)
+	}
+
+	typ := schema["type"]
+	if c, ok := schema["const"]; ok && typ == nil {
+		switch c.(type) {
+		case string:
+			typ = "string"
+		case bool:
+			typ = "boolean"
+		case float64, int, uint64:
+			typ = "number"
+		}
+	}
+	if types, ok := typ.([]any); ok {
+		// A list of types is handled like the equivalent oneOf.
+		alts := make([]any, len(types))
+		for i, t := range types {
+			alts[i] = object{"type": t}
+		}
+		return g.choice(schema, alts, name)
+	}
+	switch {
+	case typ == "string":
+		return goStringType(schema), nil
+	case typ == "boolean":
+		return "bool", nil
+	case typ == "integer":
+		return "int64", nil
+	case typ == "number":
+		return "float64", nil
+	case typ == "array" || schema["items"] != nil:
+		items, err := g.typeOf(schema["items"], name+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + items, nil
+	case schema["properties"] != nil:
+		return g.structType(name, schema)
+	case typ == "object":
+		values := schema["additionalProperties"]
+		if patterns, ok := schema["patternProperties"].(object); ok && len(patterns) == 1 {
+			for _, p := range patterns {
+				values = p
+			}
+		}
+		if _, ok := values.(object); !ok {
+			return "map[string]json.RawMessage", nil
+		}
+		t, err := g.typeOf(values, name+"Value")
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + t, nil
+	}
+	return "json.RawMessage", nil
+}
+
+// goStringType returns the Go type of a string schema, based on its pattern.
+func goStringType(schema object) string {
+	pattern, _ := schema["pattern"].(string)
+	if pattern == "^0x[0-9a-f]{1,2}$" {
+		// A single byte, which may have a single digit.
+		return "hexutil.Uint64"
+	}
+	if m := hexQuantityPattern.FindStringSubmatch(pattern); m != nil {
+		if digits, err := strconv.Atoi(m[1]); err == nil && digits < 16 {
+			return "hexutil.Uint64"
+		}
+		return "*hexutil.Big"
+	}
+	if m := hexBytesPattern.FindStringSubmatch(pattern); m != nil {
+		switch m[1] {
+		case "40":
+			return "common.Address"
+		case "64":
+			return "common.Hash"
+		}
+		return "hexutil.Bytes"
+	}
+	return "string"
+}
+
+// stringEnum returns the values of a string enum schema.
+func stringEnum(schema object) ([]string, bool) {
+	enum, ok := schema["enum"].([]any)
+	if !ok {
+		return nil, false
+	}
+	values := make([]string, len(enum))
+	for i, v := range enum {
+		if values[i], ok = v.(string); !ok {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// nilable returns a type for values of t which may be absent.
+func nilable(t string) string {
+	if strings.HasPrefix(t, "*") || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") || t == "json.RawMessage" {
+		return t
+	}
+	return "*" + t
+}
+
+// typeDoc returns the doc comment of a declared type.
+func typeDoc(name string, schema object) string {
+	if title, ok := schema["title"].(string); ok && title != "" {
+		return fmt.Sprintf("// %s is the %s schema.\n", name, strings.TrimSuffix(title, "."))
+	}
+	return fmt.Sprintf("// %s is generated from the spec.\n", name)
+}
+
+// structType declares a struct for an object schema.
+func (g *goGenerator) structType(name string, schema object) (string, error) {
+	name = g.declare(name)
+	props, _ := schema["properties"].(object)
+	required := requiredSet(schema)
+	var b strings.Builder
+	b.WriteString(typeDoc(name, schema))
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	fields := make(map[string]bool)
+	for _, p := range sortedProperties(schema) {
+		field := goName(p)
+		for fields[field] {
+			field += "_"
+		}
+		fields[field] = true
+		t, err := g.typeOf(props[p], name+field)
+		if err != nil {
+			return "", fmt.Errorf("property %s: %w", p, err)
+		}
+		tag := p
+		if !required[p] {
+			t = nilable(t)
+			tag += ",omitempty"
+		}
+		var doc []string
+		if title, ok := props[p].(object)["title"].(string); ok && !strings.EqualFold(title, p) {
+			doc = append(doc, strings.ReplaceAll(title, "\n", " "))
+		}
+		since, deprecated := forkNote(props[p].(object))
+		if since != "" {
+			doc = append(doc, since)
+		}
+		if deprecated != "" {
+			doc = append(doc, "", "Deprecated: "+deprecated)
+		}
+		for _, line := range doc {
+			fmt.Fprintf(&b, "\t// %s\n", line)
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%q`\n", field, t, tag)
+	}
+	b.WriteString("}\n")
+	g.decls = append(g.decls, b.String())
+	return name, nil
+}
+
+// enum declares a string type with a constant for each value.
+func (g *goGenerator) enum(name string, schema object, values []string) string {
+	name = g.declare(name)
+	var b strings.Builder
+	b.WriteString(typeDoc(name, schema))
+	fmt.Fprintf(&b, "type %s string\n\nconst (\n", name)
+	consts := make([]string, len(values))
+	for i, v := range values {
+		consts[i] = name + goName(v)
+		fmt.Fprintf(&b, "\t%s %s = %q\n", consts[i], name, v)
+	}
+	b.WriteString(")\n\n")
+	fmt.Fprintf(&b, "// UnmarshalJSON rejects values not defined by %s.\n", name)
+	fmt.Fprintf(&b, "func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	b.WriteString("\tvar s string\n\tif err := json.Unmarshal(data, &s); err != nil {\n\t\treturn err\n\t}\n")
+	fmt.Fprintf(&b, "\tswitch %s(s) {\n\tcase %s:\n\t\t*v = %s(s)\n\t\treturn nil\n\t}\n", name, strings.Join(consts, ", "), name)
+	fmt.Fprintf(&b, "\treturn fmt.Errorf(\"invalid %s %%q\", s)\n}\n", name)
+	g.decls = append(g.decls, b.String())
+	return name
+}
+
+// isNullSchema reports whether a schema, possibly a $ref, only allows null.
+func (g *goGenerator) isNullSchema(v any) bool {
+	schema, ok := v.(object)
+	if !ok {
+		return false
+	}
+	expanded, err := g.s.expandRef(schema)
+	return err == nil && isNull(expanded)
+}
+
+// choice returns the type of a oneOf or anyOf. A choice between null and one
+// other type is that type, which may be nil. Otherwise a struct is declared
+// with a field for each alternative.
+func (g *goGenerator) choice(schema object, alts []any, name string) (string, error) {
+	var options []object
+	nullable := false
+	for _, alt := range alts {
+		if g.isNullSchema(alt) {
+			nullable = true
+		} else if obj, ok := alt.(object); ok {
+			options = append(options, obj)
+		}
+	}
+	switch len(options) {
+	case 0:
+		return "json.RawMessage", nil
+	case 1:
+		t, err := g.typeOf(options[0], name)
+		if err != nil || !nullable {
+			return t, err
+		}
+		return nilable(t), nil
+	}
+
+	name = g.declare(name)
+	type field struct {
+		name, typ, shape string
+		required         int
+	}
+	var fields []field
+	used := make(map[string]bool)
+	for i, alt := range options {
+		f := field{name: goName(g.label(alt))}
+		if f.name == "X" {
+			f.name = fmt.Sprintf("Alt%d", i)
+		}
+		for n := 2; used[f.name]; n++ {
+			f.name = strings.TrimRight(f.name, "0123456789") + strconv.Itoa(n)
+		}
+		used[f.name] = true
+		t, err := g.typeOf(alt, name+f.name)
+		if err != nil {
+			return "", fmt.Errorf("alternative %d: %w", i, err)
+		}
+		f.typ = t
+		shape, required, err := g.shape(alt)
+		if err != nil {
+			return "", fmt.Errorf("alternative %d: %w", i, err)
+		}
+		f.required = required
+		if shape != "" {
+			f.shape = strings.ToLower(name[:1]) + name[1:] + f.name + "Shape"
+			g.decls = append(g.decls, fmt.Sprintf("var %s = %s\n", f.shape, shape))
+		}
+		fields = append(fields, f)
+	}
+
+	var b strings.Builder
+	b.WriteString(typeDoc(name, schema))
+	b.WriteString("// At most one of its fields is set.\n")
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "\t%s %s\n", f.name, nilable(f.typ))
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "func (v %s) MarshalJSON() ([]byte, error) {\n\tswitch {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "\tcase v.%s != nil:\n\t\treturn json.Marshal(v.%s)\n", f.name, f.name)
+	}
+	b.WriteString("\t}\n\treturn []byte(\"null\"), nil\n}\n\n")
+
+	fmt.Fprintf(&b, "func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	fmt.Fprintf(&b, "\t*v = %s{}\n\tif string(data) == \"null\" {\n\t\treturn nil\n\t}\n", name)
+	// Objects with more required fields are tried first, so that an object
+	// which extends another is decoded as the larger type.
+	attempts := slices.Clone(fields)
+	slices.SortStableFunc(attempts, func(a, b field) int { return b.required - a.required })
+	for _, f := range attempts {
+		elem, ref := f.typ, "&x"
+		switch {
+		case strings.HasPrefix(elem, "*"):
+			elem = elem[1:]
+		case nilable(elem) == elem:
+			ref = "x"
+		}
+		if f.shape != "" {
+			fmt.Fprintf(&b, "\tif %s.matches(data) {\n", f.shape)
+		} else {
+			b.WriteString("\t{\n")
+		}
+		fmt.Fprintf(&b, "\t\tvar x %s\n\t\tif err := json.Unmarshal(data, &x); err == nil {\n\t\t\tv.%s = %s\n\t\t\treturn nil\n\t\t}\n\t}\n", elem, f.name, ref)
+	}
+	fmt.Fprintf(&b, "\treturn fmt.Errorf(\"invalid %s: %%s\", data)\n}\n", name)
+	g.decls = append(g.decls, b.String())
+	return name, nil
+}
+
+// label returns the name of an alternative: its title, the name of the schema
+// it references or its type.
+func (g *goGenerator) label(alt object) string {
+	if title, ok := alt["title"].(string); ok && title != "" {
+		return title
+	}
+	if ref, ok := alt["$ref"].(string); ok {
+		return unescapePointer(ref[strings.LastIndex(ref, "/")+1:])
+	}
+	if t, ok := alt["type"].(string); ok {
+		return t
+	}
+	return ""
+}
+
+// shape returns the Go expression of the shape of an object alternative and
+// its number of required fields. The shape is "" if the alternative isn't an
+// object or any object matches it.
+func (g *goGenerator) shape(alt object) (string, int, error) {
+	schema, err := g.s.objectSchema(alt)
+	if err != nil {
+		return "", 0, err
+	}
+	props, _ := schema["properties"].(object)
+	if len(props) == 0 && schema["required"] == nil {
+		return "", 0, nil
+	}
+	var parts []string
+	required := slices.Sorted(maps.Keys(requiredSet(schema)))
+	if len(required) > 0 {
+		parts = append(parts, "required: "+goStrings(required))
+	}
+	if forbidden := forbiddenFields(schema); len(forbidden) > 0 {
+		parts = append(parts, "forbidden: "+goStrings(forbidden))
+	}
+	var values []string
+	for _, p := range sortedProperties(schema) {
+		prop, ok := props[p].(object)
+		if !ok {
+			continue
+		}
+		prop, err := g.s.expandRef(prop)
+		if err != nil {
+			return "", 0, err
+		}
+		if fixed := fixedValues(prop); fixed != nil {
+			values = append(values, fmt.Sprintf("%q: {%s}", p, strings.Join(fixed, ", ")))
+		}
+	}
+	if len(values) > 0 {
+		parts = append(parts, "values: map[string][]any{"+strings.Join(values, ", ")+"}")
+	}
+	if schema["additionalProperties"] == false {
+		parts = append(parts, "fields: "+goStrings(sortedProperties(schema)))
+	}
+	if len(parts) == 0 {
+		return "", 0, nil
+	}
+	return "shape{" + strings.Join(parts, ", ") + "}", len(required), nil
+}
+
+// forbiddenFields returns the fields an object schema rules out with "not".
+func forbiddenFields(schema object) []string {
+	not, _ := schema["not"].(object)
+	var fields []string
+	for _, sub := range append([]any{not}, alternatives(not)...) {
+		obj, _ := sub.(object)
+		for name := range requiredSet(obj) {
+			fields = append(fields, name)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// fixedValues returns the Go expressions of the values a field is restricted
+// to by const, enum or a pattern matching a single literal, e.g. ^0x2$. The
+// values are written as decoded by encoding/json into an any.
+func fixedValues(prop object) []string {
+	var values []any
+	if c, ok := prop["const"]; ok {
+		values = []any{c}
+	} else if enum, ok := prop["enum"].([]any); ok {
+		values = enum
+	} else if pattern, ok := prop["pattern"].(string); ok && strings.HasPrefix(pattern, "^") && strings.HasSuffix(pattern, "$") {
+		literal := pattern[1 : len(pattern)-1]
+		if regexp.QuoteMeta(literal) != literal {
+			return nil
+		}
+		values = []any{literal}
+	}
+	var exprs []string
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			exprs = append(exprs, strconv.Quote(v))
+		case bool:
+			exprs = append(exprs, strconv.FormatBool(v))
+		case int:
+			exprs = append(exprs, fmt.Sprintf("float64(%d)", v))
+		case uint64:
+			exprs = append(exprs, fmt.Sprintf("float64(%d)", v))
+		case float64:
+			exprs = append(exprs, fmt.Sprintf("float64(%v)", v))
+		default:
+			// Objects and arrays can't be compared.
+			return nil
+		}
+	}
+	return exprs
+}
+
+func goStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// method generates the client method calling an OpenRPC method.
+func (g *goGenerator) method(name string, method object) (string, error) {
+	fn := goName(name)
+	type param struct {
+		name, typ string
+		required  bool
+	}
+	var params []param
+	used := make(map[string]bool)
+	list, _ := method["params"].([]any)
+	for i, p := range list {
+		cd, _ := p.(object)
+		cdName, _ := cd["name"].(string)
+		pn := goName(cdName)
+		pn = strings.ToLower(pn[:1]) + pn[1:]
+		if cdName == "" {
+			pn = fmt.Sprintf("param%d", i)
+		}
+		for goReserved[pn] || used[pn] {
+			pn += "_"
+		}
+		used[pn] = true
+		t, err := g.typeOf(cd["schema"], fn+goName(cdName))
+		if err != nil {
+			return "", fmt.Errorf("param %d: %w", i, err)
+		}
+		required, _ := cd["required"].(bool)
+		if !required {
+			t = nilable(t)
+		}
+		params = append(params, param{pn, t, required})
+	}
+	result, _ := method["result"].(object)
+	resultType := ""
+	if schema, ok := result["schema"]; ok && !g.isNullSchema(schema) {
+		t, err := g.typeOf(schema, fn+"Result")
+		if err != nil {
+			return "", fmt.Errorf("result: %w", err)
+		}
+		resultType = t
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s calls %s.\n", fn, name)
+	if summary, ok := method["summary"].(string); ok && summary != "" {
+		fmt.Fprintf(&b, "//\n// %s\n", strings.ReplaceAll(strings.TrimSpace(summary), "\n", "\n// "))
+	}
+	since, deprecated := forkNote(method)
+	if since != "" {
+		fmt.Fprintf(&b, "//\n// %s\n", since)
+	}
+	if deprecated != "" {
+		fmt.Fprintf(&b, "//\n// Deprecated: %s\n", deprecated)
+	} else if deprecated, _ := method["deprecated"].(bool); deprecated {
+		b.WriteString("//\n// Deprecated: the method is deprecated by the spec.\n")
+	}
+	fmt.Fprintf(&b, "func (c *Client) %s(ctx context.Context", fn)
+	for _, p := range params {
+		fmt.Fprintf(&b, ", %s %s", p.name, p.typ)
+	}
+	b.WriteString(") ")
+	target := "nil"
+	if resultType != "" {
+		fmt.Fprintf(&b, "(%s, error) {\n\tvar result %s\n", resultType, resultType)
+		target = "&result"
+	} else {
+		b.WriteString("error {\n")
+	}
+
+	// Optional params are left out from the end of the list if not set.
+	firstOptional := len(params)
+	for firstOptional > 0 && !params[firstOptional-1].required {
+		firstOptional--
+	}
+	var args string
+	if firstOptional == len(params) {
+		for _, p := range params {
+			args += ", " + p.name
+		}
+	} else {
+		var required []string
+		for _, p := range params[:firstOptional] {
+			required = append(required, p.name)
+		}
+		fmt.Fprintf(&b, "\targs := []any{%s}\n", strings.Join(required, ", "))
+		for i, p := range params[firstOptional:] {
+			var conds []string
+			for _, rest := range params[firstOptional+i:] {
+				conds = append(conds, rest.name+" != nil")
+			}
+			fmt.Fprintf(&b, "\tif %s {\n\t\targs = append(args, %s)\n\t}\n", strings.Join(conds, " || "), p.name)
+		}
+		args = ", args..."
+	}
+	fmt.Fprintf(&b, "\terr := c.c.CallContext(ctx, %s, %q%s)\n", target, name, args)
+	if resultType != "" {
+		b.WriteString("\treturn result, err\n}\n")
+	} else {
+		b.WriteString("\treturn err\n}\n")
+	}
+	return b.String(), nil
+}
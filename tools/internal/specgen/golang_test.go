@@ -0,0 +1,149 @@
+package specgen
+
+import (
+	"strings"
+	"testing"
+)
+
+const goSchemas = `
+uint64:
+  type: string
+  pattern: ^0x(0|[1-9a-f][0-9a-f]{0,15})$
+uint256:
+  type: string
+  pattern: ^0x(0|[1-9a-f][0-9a-f]{0,63})$
+address:
+  type: string
+  pattern: ^0x[0-9a-fA-F]{40}$
+bytes:
+  type: string
+  pattern: ^0x[0-9a-f]*$
+Tag:
+  title: Block tag
+  type: string
+  enum:
+    - latest
+    - pending
+NumberOrTag:
+  oneOf:
+    - title: Block number
+      $ref: '#/components/schemas/uint64'
+    - $ref: '#/components/schemas/Tag'
+Account:
+  type: object
+  required: [address]
+  properties:
+    address:
+      $ref: '#/components/schemas/address'
+    balance:
+      $ref: '#/components/schemas/uint256'
+    code:
+      title: Code
+      $ref: '#/components/schemas/bytes'
+    storage:
+      type: array
+      items:
+        type: object
+        properties:
+          key:
+            $ref: '#/components/schemas/bytes'
+Tx:
+  oneOf:
+    - title: Legacy
+      type: object
+      required: [type, gasPrice]
+      properties:
+        type:
+          type: string
+          pattern: ^0x0$
+        gasPrice:
+          $ref: '#/components/schemas/uint256'
+    - title: Dynamic fee
+      type: object
+      required: [type, maxFeePerGas]
+      properties:
+        type:
+          type: string
+          pattern: ^0x2$
+        maxFeePerGas:
+          $ref: '#/components/schemas/uint256'
+`
+
+const goMethods = `
+- name: eth_getAccount
+  summary: Returns an account.
+  params:
+    - name: Address
+      required: true
+      schema:
+        $ref: '#/components/schemas/address'
+    - name: Block
+      schema:
+        $ref: '#/components/schemas/NumberOrTag'
+  result:
+    name: Account
+    schema:
+      oneOf:
+        - type: 'null'
+        - $ref: '#/components/schemas/Account'
+- name: eth_send
+  params:
+    - name: type
+      required: true
+      schema:
+        $ref: '#/components/schemas/Tx'
+  result:
+    name: Nothing
+    schema:
+      type: 'null'
+`
+
+func TestGo(t *testing.T) {
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(goSchemas)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(goMethods)); err != nil {
+		t.Fatal(err)
+	}
+	code, err := g.Go("bindings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(code)
+
+	for _, want := range []string{
+		"package bindings\n",
+		"\t\"slices\"\n\n\t\"github.com/ethereum/go-ethereum/common\"\n",
+		// Structs, with optional fields as pointers.
+		"type Account struct {\n" +
+			"\tAddress common.Address       `json:\"address\"`\n" +
+			"\tBalance *hexutil.Big         `json:\"balance,omitempty\"`\n" +
+			"\tCode    *hexutil.Bytes       `json:\"code,omitempty\"`\n" +
+			"\tStorage []AccountStorageItem `json:\"storage,omitempty\"`\n" +
+			"}\n",
+		"type AccountStorageItem struct {\n\tKey *hexutil.Bytes `json:\"key,omitempty\"`\n}\n",
+		// Enums.
+		"type Tag string\n",
+		"TagLatest  Tag = \"latest\"\n",
+		// Sum types.
+		"type NumberOrTag struct {\n\tBlockNumber *hexutil.Uint64\n\tTag         *Tag\n}\n",
+		"func (v *NumberOrTag) UnmarshalJSON(data []byte) error {\n",
+		"var txDynamicFeeShape = shape{required: []string{\"maxFeePerGas\", \"type\"}, values: map[string][]any{\"type\": {\"0x2\"}}}\n",
+		"if txDynamicFeeShape.matches(data) {\n",
+		"type shape struct {\n",
+		// Client methods, with optional params left out and nullable results.
+		"// EthGetAccount calls eth_getAccount.\n//\n// Returns an account.\n",
+		"func (c *Client) EthGetAccount(ctx context.Context, address common.Address, block *NumberOrTag) (*Account, error) {\n",
+		"if block != nil {\n\t\targs = append(args, block)\n\t}\n",
+		"func (c *Client) EthSend(ctx context.Context, type_ Tx) error {\n",
+		"err := c.c.CallContext(ctx, nil, \"eth_send\", type_)\n",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code is missing:\n%s", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(src)
+	}
+}
@@ -0,0 +1,145 @@
+package specgen
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-jsonpointer"
+)
+
+// schemaRef is a $ref to a component schema.
+type schemaRef struct {
+	pointer string // location of the $ref within its definition
+	ref     string
+	name    string // referenced schema
+	path    string // pointer within the referenced schema, if any
+	err     error  // set if the $ref couldn't be parsed
+}
+
+// collectRefs appends the component schema references within v to refs.
+// References to error groups are skipped, they are handled by
+// ResolveErrorGroups.
+func collectRefs(v any, pointer string, refs *[]schemaRef) {
+	switch val := v.(type) {
+	case object:
+		if ref, ok := val["$ref"].(string); ok && !strings.HasPrefix(ref, errorGroupRefPrefix) {
+			name, path, err := parseSchemaRef(ref)
+			*refs = append(*refs, schemaRef{pointer: pointer + "/$ref", ref: ref, name: name, path: path, err: err})
+		}
+		for _, k := range slices.Sorted(maps.Keys(val)) {
+			collectRefs(val[k], pointer+"/"+escapePointer(k), refs)
+		}
+	case []any:
+		for i, item := range val {
+			collectRefs(item, pointer+"/"+strconv.Itoa(i), refs)
+		}
+	}
+}
+
+// refGraph holds the schema references made by each method and schema.
+type refGraph struct {
+	methods map[string][]schemaRef
+	schemas map[string][]schemaRef
+}
+
+func (s *Generator) refGraph() refGraph {
+	g := refGraph{
+		methods: make(map[string][]schemaRef, len(s.methods)),
+		schemas: make(map[string][]schemaRef, len(s.types)),
+	}
+	for name, method := range s.methods {
+		var refs []schemaRef
+		collectRefs(method, "", &refs)
+		g.methods[name] = refs
+	}
+	for name, schema := range s.types {
+		var refs []schemaRef
+		collectRefs(schema, "", &refs)
+		g.schemas[name] = refs
+	}
+	return g
+}
+
+// reachable returns the names of the schemas referenced, directly or through
+// other schemas, by the given methods.
+func (g refGraph) reachable(methods []string) map[string]bool {
+	seen := make(map[string]bool)
+	var visit func(refs []schemaRef)
+	visit = func(refs []schemaRef) {
+		for _, r := range refs {
+			if r.err != nil || seen[r.name] {
+				continue
+			}
+			if _, ok := g.schemas[r.name]; !ok {
+				continue
+			}
+			seen[r.name] = true
+			visit(g.schemas[r.name])
+		}
+	}
+	for _, name := range methods {
+		visit(g.methods[name])
+	}
+	return seen
+}
+
+// CheckRefs checks that every $ref to a component schema, in the methods and
+// in the schemas themselves, resolves. All unresolved references are reported.
+//
+// It must be called before Dereference, which removes the references.
+func (s *Generator) CheckRefs() error {
+	g := s.refGraph()
+	var errs []error
+	check := func(kind, name string, refs []schemaRef) {
+		for _, r := range refs {
+			if err := s.resolves(r); err != nil {
+				errs = append(errs, s.errorAt(kind+"s/"+name, r.pointer, fmt.Errorf("%s %s: %w", kind, name, err)))
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(g.methods)) {
+		check("method", name, g.methods[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(g.schemas)) {
+		check("schema", name, g.schemas[name])
+	}
+	return errors.Join(errs...)
+}
+
+// resolves checks that the target of r exists.
+func (s *Generator) resolves(r schemaRef) error {
+	if r.err != nil {
+		return r.err
+	}
+	schema, ok := s.types[r.name]
+	if !ok {
+		return fmt.Errorf("$ref %q: schema %s not found", r.ref, r.name)
+	}
+	if r.path != "" {
+		if _, err := jsonpointer.Get(schema, r.path); err != nil {
+			return fmt.Errorf("$ref %q: %s not found in schema %s", r.ref, r.path, r.name)
+		}
+	}
+	return nil
+}
+
+// UnusedSchemas returns an error for each schema which isn't referenced by
+// any method, either directly or through other schemas. Such schemas are
+// usually stale definitions left behind by a change to the methods.
+//
+// It must be called before Dereference, which removes the references.
+func (s *Generator) UnusedSchemas() []error {
+	g := s.refGraph()
+	used := g.reachable(slices.Collect(maps.Keys(s.methods)))
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(s.types)) {
+		if !used[name] {
+			errs = append(errs, s.errorAt("schemas/"+name, "", fmt.Errorf("schema %s is not used by any method", name)))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,110 @@
+package specgen
+
+import (
+	"strings"
+	"testing"
+)
+
+const refsSchemas = `
+uint:
+  type: string
+Pair:
+  type: object
+  properties:
+    a:
+      $ref: '#/components/schemas/uint'
+    b:
+      $ref: '#/components/schemas/Inner/properties/x'
+Inner:
+  type: object
+  properties:
+    x:
+      type: boolean
+Stale:
+  type: string
+StaleToo:
+  $ref: '#/components/schemas/Stale'
+`
+
+func TestCheckRefs(t *testing.T) {
+	methods := `
+- name: test_pair
+  params:
+    - name: Pair
+      schema:
+        $ref: '#/components/schemas/Pair'
+    - name: Missing
+      schema:
+        $ref: '#/components/schemas/Missing'
+  result:
+    name: Value
+    schema:
+      $ref: '#/components/schemas/Inner/properties/y'
+`
+	schemas := refsSchemas + `
+Broken:
+  $ref: '#/components/schemas/Gone'
+`
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(schemas)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(methods)); err != nil {
+		t.Fatal(err)
+	}
+	err := g.CheckRefs()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	want := []string{
+		`methods.yaml:9:9: method test_pair: $ref "#/components/schemas/Missing": schema Missing not found`,
+		`methods.yaml:13:7: method test_pair: $ref "#/components/schemas/Inner/properties/y": /properties/y not found in schema Inner`,
+		`schemas.yaml:22:3: schema Broken: $ref "#/components/schemas/Gone": schema Gone not found`,
+	}
+	got := strings.Split(err.Error(), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of errors, got:\n%v", err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("error %d:\ngot  %s\nwant %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUnusedSchemas(t *testing.T) {
+	methods := `
+- name: test_pair
+  params:
+    - name: Pair
+      schema:
+        $ref: '#/components/schemas/Pair'
+  result:
+    name: Nothing
+    schema:
+      type: 'null'
+`
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(refsSchemas)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(methods)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.CheckRefs(); err != nil {
+		t.Fatal(err)
+	}
+	unused := g.UnusedSchemas()
+	want := []string{
+		"schemas.yaml:16:1: schema Stale is not used by any method",
+		"schemas.yaml:18:1: schema StaleToo is not used by any method",
+	}
+	if len(unused) != len(want) {
+		t.Fatalf("wrong unused schemas: %v", unused)
+	}
+	for i := range want {
+		if unused[i].Error() != want[i] {
+			t.Errorf("unused %d:\ngot  %v\nwant %s", i, unused[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package specgen
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// position is a location within a YAML source file. Line and column are
+// 1-based, and zero if unknown.
+type position struct {
+	file      string
+	line, col int
+}
+
+func (p position) String() string {
+	switch {
+	case p.line == 0:
+		return p.file
+	case p.col == 0:
+		return fmt.Sprintf("%s:%d", p.file, p.line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", p.file, p.line, p.col)
+	}
+}
+
+// sourceError is an error located in a source file.
+type sourceError struct {
+	pos position
+	err error
+}
+
+func (e *sourceError) Error() string { return e.pos.String() + ": " + e.err.Error() }
+func (e *sourceError) Unwrap() error { return e.err }
+
+// docError is an error at a JSON pointer within the built document. It is
+// translated into a sourceError by the generator.
+type docError struct {
+	pointer string
+	err     error
+}
+
+func (e *docError) Error() string { return e.err.Error() }
+func (e *docError) Unwrap() error { return e.err }
+
+// source records where a definition (a method, schema or error group) was
+// read from. Positions are keyed by JSON pointer relative to the definition,
+// with the empty pointer being the definition itself.
+type source struct {
+	file      string
+	positions map[string]position
+}
+
+// newSource records the positions of node and everything nested within it.
+// The definition itself is located at pos.
+func newSource(pos position, node *yaml.Node) *source {
+	src := &source{file: pos.file, positions: make(map[string]position)}
+	src.walk(node, "", pos)
+	return src
+}
+
+// nodePosition returns the position of a YAML node in file.
+func nodePosition(file string, node *yaml.Node) position {
+	return position{file, node.Line, node.Column}
+}
+
+func (src *source) walk(node *yaml.Node, ptr string, pos position) {
+	src.positions[ptr] = pos
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			// Fields are located at their key, which is where an editor would
+			// look for them.
+			src.walk(value, ptr+"/"+escapePointer(key.Value), nodePosition(src.file, key))
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			src.walk(item, ptr+"/"+strconv.Itoa(i), nodePosition(src.file, item))
+		}
+	}
+}
+
+// position returns the position of the value at ptr, or of its nearest
+// enclosing value if ptr itself wasn't read from the source, e.g. because it
+// was expanded from a $ref.
+func (src *source) position(ptr string) position {
+	for {
+		if pos, ok := src.positions[ptr]; ok {
+			return pos
+		}
+		i := strings.LastIndex(ptr, "/")
+		if i < 0 {
+			return position{file: src.file}
+		}
+		ptr = ptr[:i]
+	}
+}
+
+// at wraps err with the position of the value at ptr.
+func (src *source) at(ptr string, err error) error {
+	return &sourceError{pos: src.position(ptr), err: err}
+}
+
+// parseYAML parses content into a YAML node, or nil if the content is empty.
+// Syntax errors are located in file.
+func parseYAML(file string, content []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, yamlError(file, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlError converts an error returned by the YAML library, which reports
+// positions as "yaml: line N: ...", into a sourceError.
+func yamlError(file string, err error) error {
+	msg := err.Error()
+	var te *yaml.TypeError
+	if errors.As(err, &te) && len(te.Errors) > 0 {
+		msg = te.Errors[0]
+	}
+	pos := position{file: file}
+	if m := yamlErrorLine.FindStringSubmatch(msg); m != nil {
+		pos.line, _ = strconv.Atoi(m[1])
+		msg = m[2]
+	}
+	return &sourceError{pos: pos, err: errors.New(msg)}
+}
+
+// escapePointer escapes a key for use as a JSON pointer token.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// unescapePointer unescapes a JSON pointer token.
+func unescapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
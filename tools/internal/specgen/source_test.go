@@ -0,0 +1,118 @@
+package specgen
+
+import (
+	"strings"
+	"testing"
+)
+
+const sourceSchemas = `
+uint:
+  type: string
+  pattern: ^0x(0|[1-9a-f][0-9a-f]*)$
+Pair:
+  type: object
+  properties:
+    a:
+      $ref: '#/components/schemas/uint'
+    b:
+      $ref: '#/components/schemas/missing'
+`
+
+const sourceMethods = `
+- name: test_one
+  params: []
+  result:
+    name: One
+    schema:
+      $ref: '#/components/schemas/uint'
+  examples:
+    - name: test_one example
+      params: []
+      result:
+        name: One
+        value: '0x01'
+`
+
+func TestSourcePositions(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(g *Generator) error
+		want string
+	}{
+		{
+			name: "yaml syntax",
+			run: func(g *Generator) error {
+				return g.AddSchemas("bad.yaml", []byte("a:\n  b: [1\n"))
+			},
+			want: "bad.yaml:1: ",
+		},
+		{
+			name: "duplicate schema",
+			run: func(g *Generator) error {
+				return g.AddSchemas("dup.yaml", []byte("\nuint:\n  type: string\n"))
+			},
+			want: "dup.yaml:2:1: duplicate schema uint, already defined at schemas.yaml:2:1",
+		},
+		{
+			name: "duplicate method",
+			run: func(g *Generator) error {
+				return g.AddMethods("dup.yaml", []byte("- name: test_one\n  params: []\n"))
+			},
+			want: "dup.yaml:1:3: method test_one already defined at methods.yaml:2:3",
+		},
+		{
+			name: "unnamed method",
+			run: func(g *Generator) error {
+				return g.AddMethods("more.yaml", []byte("- name: test_two\n- params: []\n"))
+			},
+			want: "more.yaml:2:3: method 1 has no name",
+		},
+		{
+			name: "missing ref",
+			run: func(g *Generator) error {
+				return g.Dereference()
+			},
+			want: "schemas.yaml:11:7: schema Pair: at /properties/b/$ref",
+		},
+		{
+			name: "out of range error code",
+			run: func(g *Generator) error {
+				groups := `
+Test:
+  range: {min: 100, max: 199}
+  errors:
+    - code: 100
+      message: ok
+    - code: 200
+      message: out of range
+`
+				return g.AddErrorGroups("groups.yaml", []byte(groups))
+			},
+			want: "groups.yaml:7:7: ",
+		},
+		{
+			name: "invalid example",
+			run: func(g *Generator) error {
+				return g.Validate()
+			},
+			want: "methods.yaml:11:7: method test_one",
+		},
+	}
+	for _, tt := range tests {
+		g := New()
+		if err := g.AddSchemas("schemas.yaml", []byte(sourceSchemas)); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.AddMethods("methods.yaml", []byte(sourceMethods)); err != nil {
+			t.Fatal(err)
+		}
+		err := tt.run(g)
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error should contain %q, got: %v", tt.name, tt.want, err)
+		}
+	}
+}
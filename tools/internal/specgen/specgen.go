@@ -4,11 +4,14 @@ import (
 	"bytes"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"maps"
 	"os"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/ethereum/execution-apis/tools/internal/metaschema"
 	"github.com/santhosh-tekuri/jsonschema/v6"
@@ -27,6 +30,10 @@ type Generator struct {
 	methods     map[string]object
 	types       schemaRepository
 	errorGroups errorGroups
+
+	// sources records where each definition was read from, keyed by
+	// "methods/<name>", "schemas/<name>" or "error-groups/<name>".
+	sources map[string]*source
 }
 
 func New() *Generator {
@@ -42,90 +49,120 @@ func New() *Generator {
 		methods: make(map[string]object),
 		types:   make(schemaRepository),
 		baseDoc: baseDocMap,
+		sources: make(map[string]*source),
 	}
 }
 
-// AddMethods parses the given YAML content and adds the methods defined within it to the spec.
+// AddMethods parses the given YAML content of the named file and adds the
+// methods defined within it to the spec.
 //
 // The input is assumed to be a list of method objects.
-func (s *Generator) AddMethods(methods []byte) error {
-	methodSchemas, err := parseMethods(methods)
-	if err != nil {
-		return fmt.Errorf("failed to parse methods: %v", err)
+func (s *Generator) AddMethods(file string, content []byte) error {
+	node, err := parseYAML(file, content)
+	if err != nil || node == nil {
+		return err
 	}
-	for i, method := range methodSchemas {
+	if node.Kind != yaml.SequenceNode {
+		return &sourceError{nodePosition(file, node), fmt.Errorf("methods must be a list")}
+	}
+	for i, item := range node.Content {
+		src := newSource(nodePosition(file, item), item)
+		var method object
+		if err := item.Decode(&method); err != nil {
+			return yamlError(file, err)
+		}
 		nameVal, ok := method["name"]
 		if !ok {
-			return fmt.Errorf("method %d has no name", i)
+			return src.at("", fmt.Errorf("method %d has no name", i))
 		}
 		name, ok := nameVal.(string)
 		if !ok {
-			return fmt.Errorf("method %d name is not a string: %v", i, nameVal)
+			return src.at("/name", fmt.Errorf("method %d name is not a string: %v", i, nameVal))
 		}
 		if _, exists := s.methods[name]; exists {
-			return fmt.Errorf("method %s already defined", name)
+			return src.at("", fmt.Errorf("method %s already defined at %v", name, s.sources["methods/"+name].position("")))
 		}
 		s.methods[name] = method
+		s.sources["methods/"+name] = src
 	}
 	return nil
 }
 
-func parseMethods(content []byte) ([]object, error) {
-	var body []object
-	err := yaml.Unmarshal(content, &body)
-	if err != nil {
-		err = fmt.Errorf("failed to unmarshal methods: %v", err)
-	}
-	return body, err
-}
-
-// AddSchemas parses the given YAML content and adds the given schemas (type definitions)
-// to the generator.
+// AddSchemas parses the given YAML content of the named file and adds the given
+// schemas (type definitions) to the generator.
 //
 // The input is assumed to be of object shape, i.e. it contains key-value pairs.
-func (s *Generator) AddSchemas(schemas []byte) error {
-	parsedSchema, err := parseSchema(schemas)
-	if err != nil {
-		return fmt.Errorf("failed to parse schemas: %v", err)
+func (s *Generator) AddSchemas(file string, content []byte) error {
+	node, err := parseYAML(file, content)
+	if err != nil || node == nil {
+		return err
+	}
+	if node.Kind != yaml.MappingNode {
+		return &sourceError{nodePosition(file, node), fmt.Errorf("invalid schema content: schemas must be a mapping")}
 	}
-	for key, schema := range parsedSchema {
-		if _, exists := s.types[key]; exists {
-			return fmt.Errorf("duplicate schema %s", key)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		src := newSource(nodePosition(file, key), value)
+		var schema object
+		if err := value.Decode(&schema); err != nil {
+			return yamlError(file, err)
 		}
-		s.types[key] = schema
+		if _, exists := s.types[key.Value]; exists {
+			return src.at("", fmt.Errorf("duplicate schema %s, already defined at %v", key.Value, s.sources["schemas/"+key.Value].position("")))
+		}
+		s.types[key.Value] = schema
+		s.sources["schemas/"+key.Value] = src
 	}
 	return nil
 }
 
-func parseSchema(content []byte) (map[string]object, error) {
-	var body map[string]object
-	err := yaml.Unmarshal(content, &body)
-	if err != nil {
-		return nil, fmt.Errorf("invalid schema content: %v", err)
+// AddErrorGroups parses the given YAML content of the named file and adds the
+// error group definitions
+func (s *Generator) AddErrorGroups(file string, content []byte) error {
+	node, err := parseYAML(file, content)
+	if err != nil || node == nil {
+		return err
 	}
-	return body, nil
-}
-
-// AddErrorGroups parses the given YAML content and adds the error group definitions
-func (s *Generator) AddErrorGroups(content []byte) error {
 	groups, err := parseErrorGroups(content)
 	if err != nil {
-		return fmt.Errorf("failed to parse error groups: %v", err)
+		return yamlError(file, err)
 	}
-	for _, entry := range groups {
+	// Add the groups in the order they are defined in the file.
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		entry, _ := groups.find(key.Value)
+		src := newSource(nodePosition(file, key), value)
 		if _, exists := s.errorGroups.find(entry.Name); exists {
-			return fmt.Errorf("duplicate error group %s", entry.Name)
+			return src.at("", fmt.Errorf("duplicate error group %s, already defined at %v", entry.Name, s.sources["error-groups/"+entry.Name].position("")))
+		}
+		for j, e := range entry.Errors {
+			if err := entry.validateErrorCode(e); err != nil {
+				return src.at(fmt.Sprintf("/errors/%d/code", j), err)
+			}
 		}
 		if err := s.errorGroups.checkRangeOverlap(entry); err != nil {
-			return err
+			return src.at("/range", err)
 		}
 		s.errorGroups = append(s.errorGroups, entry)
+		s.sources["error-groups/"+entry.Name] = src
 	}
 	return nil
 }
 
-// ResolveErrorGroups resolves all error-groups references in method definitions, merged with existing inline errors
-func (s *Generator) ResolveErrorGroups() error {
+// ResolveErrorGroups resolves all error-groups references in method definitions, merged with existing inline errors.
+// It returns a warning for each inline error which overrides a group error with a different message.
+func (s *Generator) ResolveErrorGroups() (warnings []error, err error) {
+	for _, group := range s.errorGroups {
+		for i, parent := range group.Extends {
+			if _, ok := s.errorGroups.find(parent); !ok {
+				err := fmt.Errorf("error group %s extends unknown group %s", group.Name, parent)
+				return nil, s.errorAt("error-groups/"+group.Name, fmt.Sprintf("/extends/%d", i), err)
+			}
+		}
+		if _, err := s.errorGroups.groupErrors(group.Name, nil); err != nil {
+			return nil, s.errorAt("error-groups/"+group.Name, "/extends", err)
+		}
+	}
 	for _, name := range slices.Sorted(maps.Keys(s.methods)) {
 		method := s.methods[name]
 		errorGroupRefs, hasGroups := method["error-groups"].([]any)
@@ -133,9 +170,14 @@ func (s *Generator) ResolveErrorGroups() error {
 			continue
 		}
 		existingErrors, _ := method["errors"].([]any)
-		resolved, err := s.errorGroups.resolveMethodErrors(existingErrors, errorGroupRefs)
+		resolved, overrides, err := s.errorGroups.resolveMethodErrors(existingErrors, errorGroupRefs)
 		if err != nil {
-			return fmt.Errorf("method %s: %w", name, err)
+			return nil, s.errorAt("methods/"+name, "/error-groups", fmt.Errorf("method %s: %w", name, err))
+		}
+		for _, o := range overrides {
+			inline := existingErrors[o.index].(object)
+			err := fmt.Errorf("method %s: error %v %q overrides %q of error group %s", name, inline["code"], inline["message"], o.message, o.group)
+			warnings = append(warnings, s.errorAt("methods/"+name, fmt.Sprintf("/errors/%d", o.index), err))
 		}
 		method = maps.Clone(method)
 		method["errors"] = resolved
@@ -143,7 +185,7 @@ func (s *Generator) ResolveErrorGroups() error {
 		s.methods[name] = method
 	}
 	s.errorGroups = nil
-	return nil
+	return warnings, nil
 }
 
 // Dereference removes all $ref pointers and ensures the spec and does not use the `allOf`
@@ -156,7 +198,7 @@ func (s *Generator) Dereference() error {
 	for name, schema := range s.types {
 		exp, err := s.expandSchema(schema, s.types)
 		if err != nil {
-			return fmt.Errorf("schema %s: %w", name, err)
+			return s.derefErrorAt("schemas/"+name, fmt.Errorf("schema %s: %w", name, err))
 		}
 		types[name] = exp
 	}
@@ -166,7 +208,7 @@ func (s *Generator) Dereference() error {
 	for _, name := range slices.Sorted(maps.Keys(s.methods)) {
 		method, err := s.expandMethod(s.methods[name], types)
 		if err != nil {
-			return fmt.Errorf("method %s: %w", name, err)
+			return s.derefErrorAt("methods/"+name, fmt.Errorf("method %s: %w", name, err))
 		}
 		methods[name] = method
 	}
@@ -204,7 +246,7 @@ func (s *Generator) expandMethod(method object, types map[string]object) (object
 			if schema, ok := param["schema"].(object); ok {
 				exp, err := s.expandSchema(schema, types)
 				if err != nil {
-					return nil, fmt.Errorf("param %d schema: %w", i, err)
+					return nil, fmt.Errorf("param %d schema: %w", i, withPointerPrefix(fmt.Sprintf("/params/%d/schema", i), err))
 				}
 				param["schema"] = exp
 			}
@@ -219,7 +261,7 @@ func (s *Generator) expandMethod(method object, types map[string]object) (object
 		if schema, ok := result["schema"].(object); ok {
 			exp, err := s.expandSchema(schema, types)
 			if err != nil {
-				return nil, fmt.Errorf("result schema: %w", err)
+				return nil, fmt.Errorf("result schema: %w", withPointerPrefix("/result/schema", err))
 			}
 			result["schema"] = exp
 		}
@@ -239,7 +281,7 @@ func (s *Generator) expandMethod(method object, types map[string]object) (object
 			if schema, ok := errObj["data"].(object); ok {
 				exp, err := s.expandSchema(schema, types)
 				if err != nil {
-					return nil, fmt.Errorf("error %d data schema: %w", i, err)
+					return nil, fmt.Errorf("error %d data schema: %w", i, withPointerPrefix(fmt.Sprintf("/errors/%d/data", i), err))
 				}
 				errObj["data"] = exp
 			}
@@ -271,18 +313,82 @@ func repo2object(r schemaRepository) object {
 	return obj
 }
 
-// Validate builds the spec and checks it against the OpenRPC meta-schema.
+// Validate builds the spec and checks it against the OpenRPC meta-schema. The
+// method examples are also checked against the method schemas.
 func (s *Generator) Validate() error {
 	doc := s.build()
 	if err := validate(doc); err != nil {
-		return fmt.Errorf("spec is invalid: %v", err)
+		return fmt.Errorf("spec is invalid: %w", s.locate(err))
 	}
 	return nil
 }
 
+// errorAt locates err at the given JSON pointer within a definition, if the
+// definition's source is known.
+func (s *Generator) errorAt(def, ptr string, err error) error {
+	src, ok := s.sources[def]
+	if !ok {
+		return err
+	}
+	return src.at(ptr, err)
+}
+
+// derefErrorAt locates an error dereferencing the given definition at the $ref
+// which failed to expand. The $ref may be in another schema reached through
+// the definition.
+func (s *Generator) derefErrorAt(def string, err error) error {
+	var de *derefError
+	if !errors.As(err, &de) {
+		return s.errorAt(def, "", err)
+	}
+	if de.schema != "" {
+		def = "schemas/" + de.schema
+	}
+	return s.errorAt(def, de.pointer, err)
+}
+
+// locate translates errors at locations within the built document into errors
+// located in the source files.
+func (s *Generator) locate(err error) error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, s.locate(e))
+		}
+		return errors.Join(errs...)
+	}
+	var de *docError
+	if !errors.As(err, &de) {
+		return err
+	}
+	tokens := strings.Split(strings.TrimPrefix(de.pointer, "/"), "/")
+	switch {
+	case len(tokens) >= 2 && tokens[0] == "methods":
+		// Methods are sorted by name in the document.
+		names := slices.Sorted(maps.Keys(s.methods))
+		i, err2 := strconv.Atoi(tokens[1])
+		if err2 != nil || i >= len(names) {
+			return err
+		}
+		return s.errorAt("methods/"+names[i], pointerFrom(tokens[2:]), err)
+	case len(tokens) >= 3 && tokens[0] == "components" && tokens[1] == "schemas":
+		name := unescapePointer(tokens[2])
+		return s.errorAt("schemas/"+name, pointerFrom(tokens[3:]), err)
+	}
+	return err
+}
+
+func pointerFrom(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(tokens, "/")
+}
+
+// validate checks the document against the OpenRPC meta-schema and validates
+// the examples. Errors are reported as docErrors.
 func validate(doc object) error {
-	err := metaschema.Validate(doc)
-	if err != nil {
+	if verr := metaschema.Validate(doc); verr != nil {
 		tmpfile, err := os.CreateTemp("", "doc-*.json")
 		if err != nil {
 			return fmt.Errorf("failed to create tmpfile: %v", err)
@@ -292,15 +398,42 @@ func validate(doc object) error {
 			return fmt.Errorf("failed to encode document: %v", err)
 		}
 		log.Printf("spec is invalid, written to %s\n", tmpfile.Name())
+		return &docError{pointer: instancePointer(verr), err: verr}
 	}
-	return err
+	return validateExamples(doc)
+}
+
+// instancePointer returns the location of the most deeply nested value which
+// failed validation.
+func instancePointer(err error) string {
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return ""
+	}
+	var deepest []string
+	var walk func(*jsonschema.ValidationError)
+	walk = func(ve *jsonschema.ValidationError) {
+		if len(ve.InstanceLocation) > len(deepest) {
+			deepest = ve.InstanceLocation
+		}
+		for _, cause := range ve.Causes {
+			walk(cause)
+		}
+	}
+	walk(ve)
+	tokens := make([]string, len(deepest))
+	for i, t := range deepest {
+		tokens[i] = escapePointer(t)
+	}
+	return pointerFrom(tokens)
 }
 
-// JSON creates the spec document.
+// JSON creates the spec document. Its arrays and objects are in canonical
+// order, see canonicalize.
 func (s *Generator) JSON() ([]byte, error) {
 	doc := s.build()
 	if err := validate(doc); err != nil {
-		return nil, fmt.Errorf("spec is invalid: %v", err)
+		return nil, fmt.Errorf("spec is invalid: %w", s.locate(err))
 	}
-	return json.MarshalIndent(doc, "", "  ")
+	return json.MarshalIndent(canonicalize(doc), "", "  ")
 }
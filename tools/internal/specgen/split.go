@@ -0,0 +1,97 @@
+package specgen
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// namespace returns the namespace of a method, i.e. the part of its name
+// before the first underscore.
+func namespace(method string) string {
+	ns, _, _ := strings.Cut(method, "_")
+	return ns
+}
+
+// subset returns a generator holding the methods for which keep returns true,
+// and the schemas they use.
+func (s *Generator) subset(keep func(name string, method object) bool) *Generator {
+	sub := &Generator{
+		baseDoc:     s.baseDoc,
+		methods:     make(map[string]object),
+		types:       make(schemaRepository),
+		errorGroups: s.errorGroups,
+		sources:     s.sources,
+	}
+	for name, method := range s.methods {
+		if keep(name, method) {
+			sub.methods[name] = method
+		}
+	}
+	used := s.refGraph().reachable(slices.Collect(maps.Keys(sub.methods)))
+	for name := range used {
+		sub.types[name] = s.types[name]
+	}
+	return sub
+}
+
+// Split divides the spec into parts, each holding a subset of the methods and
+// only the schemas used by them. Parts are keyed by name.
+//
+// When splitting by namespace, there is a part for each method namespace, e.g.
+// "eth" or "engine". When splitting by fork, there is a part for each fork
+// which introduces or deprecates a method, e.g. "prague". Parts are cumulative:
+// a part holds the methods available in its fork, i.e. those introduced by it
+// or an earlier fork, plus methods not tied to any fork. Forks which don't
+// change the methods get no part; their methods are those of the last part
+// before them. Splitting by both splits each namespace containing fork-specific
+// methods by fork, giving parts like "engine-prague".
+//
+// Split must be called before Dereference.
+func (s *Generator) Split(byNamespace, byFork bool) map[string]*Generator {
+	parts := map[string]*Generator{"": s}
+	if byNamespace {
+		parts = make(map[string]*Generator)
+		for name := range s.methods {
+			ns := namespace(name)
+			if _, ok := parts[ns]; !ok {
+				parts[ns] = s.subset(func(name string, _ object) bool { return namespace(name) == ns })
+			}
+		}
+	}
+	if byFork {
+		for _, name := range slices.Collect(maps.Keys(parts)) {
+			part := parts[name]
+			changes := part.methodForks()
+			if len(changes) == 0 {
+				continue
+			}
+			delete(parts, name)
+			for _, i := range changes {
+				fork := forks[i]
+				if name != "" {
+					fork = name + "-" + fork
+				}
+				parts[fork] = part.subset(func(_ string, method object) bool { return methodFork(method) <= i })
+			}
+		}
+	}
+	return parts
+}
+
+// methodForks returns the indexes in forks of the forks which introduce or
+// deprecate a method, in order.
+func (s *Generator) methodForks() []int {
+	set := make(map[int]bool)
+	for _, method := range s.methods {
+		if fork := methodFork(method); fork >= 0 {
+			set[fork] = true
+		}
+		if name, ok := method[deprecatedKey].(string); ok {
+			if fork := slices.Index(forks, name); fork >= 0 {
+				set[fork] = true
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(set))
+}
@@ -0,0 +1,110 @@
+package specgen
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+const splitSchemas = `
+uint:
+  type: string
+Old:
+  type: object
+  properties:
+    a:
+      $ref: '#/components/schemas/uint'
+New:
+  type: boolean
+`
+
+const splitMethods = `
+- name: eth_one
+  params: []
+  result:
+    name: One
+    schema:
+      $ref: '#/components/schemas/uint'
+- name: engine_old
+  externalDocs:
+    url: https://github.com/ethereum/execution-apis/blob/main/src/engine/paris.md#engine_old
+  params: []
+  result:
+    name: Old
+    schema:
+      $ref: '#/components/schemas/Old'
+- name: engine_new
+  externalDocs:
+    url: https://github.com/ethereum/execution-apis/blob/main/src/engine/cancun.md#engine_new
+  params: []
+  result:
+    name: New
+    schema:
+      $ref: '#/components/schemas/New'
+- name: engine_common
+  x-deprecated: osaka
+  externalDocs:
+    url: https://github.com/ethereum/execution-apis/blob/main/src/engine/common.md#engine_common
+  params: []
+  result:
+    name: Nothing
+    schema:
+      type: 'null'
+`
+
+func TestSplit(t *testing.T) {
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(splitSchemas)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(splitMethods)); err != nil {
+		t.Fatal(err)
+	}
+
+	type part struct{ methods, schemas []string }
+	tests := []struct {
+		byNamespace, byFork bool
+		want                map[string]part
+	}{
+		{
+			byNamespace: true,
+			want: map[string]part{
+				"eth":    {[]string{"eth_one"}, []string{"uint"}},
+				"engine": {[]string{"engine_common", "engine_new", "engine_old"}, []string{"New", "Old", "uint"}},
+			},
+		},
+		{
+			byNamespace: true,
+			byFork:      true,
+			want: map[string]part{
+				"eth":           {[]string{"eth_one"}, []string{"uint"}},
+				"engine-paris":  {[]string{"engine_common", "engine_old"}, []string{"Old", "uint"}},
+				"engine-cancun": {[]string{"engine_common", "engine_new", "engine_old"}, []string{"New", "Old", "uint"}},
+				"engine-osaka":  {[]string{"engine_common", "engine_new", "engine_old"}, []string{"New", "Old", "uint"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		parts := g.Split(tt.byNamespace, tt.byFork)
+		if got, want := slices.Sorted(maps.Keys(parts)), slices.Sorted(maps.Keys(tt.want)); !slices.Equal(got, want) {
+			t.Errorf("namespace=%v fork=%v: wrong parts %v, want %v", tt.byNamespace, tt.byFork, got, want)
+			continue
+		}
+		for name, want := range tt.want {
+			p := parts[name]
+			if got := slices.Sorted(maps.Keys(p.methods)); !slices.Equal(got, want.methods) {
+				t.Errorf("part %s: wrong methods %v, want %v", name, got, want.methods)
+			}
+			if got := slices.Sorted(maps.Keys(p.types)); !slices.Equal(got, want.schemas) {
+				t.Errorf("part %s: wrong schemas %v, want %v", name, got, want.schemas)
+			}
+			if err := p.Validate(); err != nil {
+				t.Errorf("part %s: %v", name, err)
+			}
+		}
+	}
+	// Splitting must not modify the spec.
+	if len(g.methods) != 4 || len(g.types) != 3 {
+		t.Errorf("spec was modified: %d methods, %d schemas", len(g.methods), len(g.types))
+	}
+}
@@ -0,0 +1,407 @@
+package specgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tsTypeKey replaces references to component schemas which are kept as named
+// types when schemas are expanded for TypeScript.
+const tsTypeKey = "x-ts-type"
+
+// tsReserved are the words which can't be used as tuple element labels.
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true,
+	"else": true, "enum": true, "export": true, "extends": true, "false": true,
+	"finally": true, "for": true, "function": true, "if": true, "import": true,
+	"in": true, "instanceof": true, "new": true, "null": true, "return": true,
+	"super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true,
+	"with": true,
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsGenerator generates TypeScript declarations.
+type tsGenerator struct {
+	s     *Generator
+	named schemaRepository  // component schemas, with references replaced by tsTypeKey
+	names map[string]string // TypeScript name of each component schema
+}
+
+// TypeScript generates a TypeScript declaration module (.d.ts) for the spec.
+// It declares a type for each component schema, an interface where the schema
+// is an object, and a Methods interface giving the params and result types of
+// each method.
+//
+// The types are derived from the schemas as expanded by Dereference, with
+// allOf merged, except that references to component schemas become references
+// to their declared types.
+//
+// TypeScript must be called before Dereference.
+func (s *Generator) TypeScript() ([]byte, error) {
+	g := &tsGenerator{s: s, named: make(schemaRepository), names: make(map[string]string)}
+	used := make(map[string]bool)
+	for _, name := range slices.Sorted(maps.Keys(s.types)) {
+		g.named[name] = tsNamedRefs(s.types[name]).(object)
+		tsName := pascalCase(nameWords(name), nil)
+		unique := tsName
+		for i := 2; used[unique]; i++ {
+			unique = fmt.Sprintf("%s%d", tsName, i)
+		}
+		used[unique] = true
+		g.names[name] = unique
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by specgen. DO NOT EDIT.\n")
+	for _, name := range slices.Sorted(maps.Keys(s.types)) {
+		schema, err := g.expand(s.types[name])
+		if err != nil {
+			return nil, s.derefErrorAt("schemas/"+name, fmt.Errorf("schema %s: %w", name, err))
+		}
+		b.WriteString("\n")
+		b.WriteString(tsDoc(schema, ""))
+		if isTSInterface(schema) {
+			fmt.Fprintf(&b, "export interface %s %s\n", g.names[name], g.objectType(schema, ""))
+		} else {
+			fmt.Fprintf(&b, "export type %s = %s;\n", g.names[name], g.typeOf(schema, ""))
+		}
+	}
+
+	b.WriteString("\n/** Methods gives the params and result types of each method. */\n")
+	b.WriteString("export interface Methods {\n")
+	for _, name := range slices.Sorted(maps.Keys(s.methods)) {
+		m, err := g.method(s.methods[name])
+		if err != nil {
+			return nil, s.derefErrorAt("methods/"+name, fmt.Errorf("method %s: %w", name, err))
+		}
+		var doc []string
+		if summary, ok := s.methods[name]["summary"].(string); ok && strings.TrimSpace(summary) != "" {
+			doc = append(doc, strings.TrimSpace(summary))
+		}
+		if tags := tsForkTags(s.methods[name]); len(tags) > 0 {
+			doc = append(doc, strings.Join(tags, "\n"))
+		}
+		if len(doc) > 0 {
+			b.WriteString(tsComment(strings.Join(doc, "\n\n"), "  "))
+		}
+		fmt.Fprintf(&b, "  %s: %s;\n", name, m)
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("export type MethodName = keyof Methods;\n")
+	b.WriteString("export type Params<M extends MethodName> = Methods[M][\"params\"];\n")
+	b.WriteString("export type Result<M extends MethodName> = Methods[M][\"result\"];\n")
+	return []byte(b.String()), nil
+}
+
+// tsNamedRefs returns a copy of v in which references to whole component
+// schemas are replaced by tsTypeKey, so expandSchema keeps them. References
+// which are allOf entries are left to be merged.
+func tsNamedRefs(v any) any {
+	switch v := v.(type) {
+	case object:
+		if ref, ok := plainRef(v); ok {
+			if name, path, err := parseSchemaRef(ref); err == nil && path == "" {
+				out := object{tsTypeKey: name}
+				for _, k := range []string{"title", "description", "deprecated", sinceForkKey, deprecatedKey} {
+					if v[k] != nil {
+						out[k] = v[k]
+					}
+				}
+				return out
+			}
+		}
+		out := make(object, len(v))
+		for k, val := range v {
+			if k != "allOf" {
+				out[k] = tsNamedRefs(val)
+				continue
+			}
+			entries, _ := val.([]any)
+			merged := make([]any, len(entries))
+			for i, entry := range entries {
+				if obj, ok := entry.(object); ok && obj["$ref"] != nil {
+					merged[i] = entry
+				} else {
+					merged[i] = tsNamedRefs(entry)
+				}
+			}
+			out[k] = merged
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = tsNamedRefs(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// expand expands a schema like Dereference, keeping references to component
+// schemas.
+func (g *tsGenerator) expand(schema object) (object, error) {
+	return g.s.expandSchema(tsNamedRefs(schema).(object), g.named)
+}
+
+// isTSInterface reports whether schema is declared as an interface.
+func isTSInterface(schema object) bool {
+	_, ok := schema["properties"].(object)
+	return ok && alternatives(schema) == nil && schema[tsTypeKey] == nil
+}
+
+// typeOf returns the TypeScript type of an expanded schema. Nested object
+// types are indented by indent.
+func (g *tsGenerator) typeOf(v any, indent string) string {
+	schema, ok := v.(object)
+	if !ok || len(schema) == 0 {
+		return "unknown"
+	}
+	if name, ok := schema[tsTypeKey].(string); ok {
+		return g.names[name]
+	}
+	if c, ok := schema["const"]; ok {
+		return tsLiteral(c)
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		literals := make([]string, len(enum))
+		for i, e := range enum {
+			literals[i] = tsLiteral(e)
+		}
+		return strings.Join(literals, " | ")
+	}
+	if alts := alternatives(schema); alts != nil {
+		types := make([]string, len(alts))
+		for i, alt := range alts {
+			types[i] = tsParen(g.typeOf(alt, indent))
+		}
+		union := strings.Join(slices.Compact(types), " | ")
+		if _, ok := schema["properties"].(object); ok {
+			// The properties of merged allOf entries apply to all alternatives.
+			return g.objectType(schema, indent) + " & (" + union + ")"
+		}
+		return union
+	}
+
+	switch typ := schema["type"].(type) {
+	case []any:
+		types := make([]string, len(typ))
+		for i, t := range typ {
+			single := maps.Clone(schema)
+			single["type"] = t
+			types[i] = tsParen(g.typeOf(single, indent))
+		}
+		return strings.Join(slices.Compact(types), " | ")
+	case string:
+		switch typ {
+		case "string":
+			return "string"
+		case "boolean":
+			return "boolean"
+		case "number", "integer":
+			return "number"
+		case "null":
+			return "null"
+		case "array":
+			return g.arrayType(schema, indent)
+		case "object":
+			return g.objectType(schema, indent)
+		}
+	}
+	switch {
+	case schema["properties"] != nil:
+		return g.objectType(schema, indent)
+	case schema["items"] != nil:
+		return g.arrayType(schema, indent)
+	}
+	return "unknown"
+}
+
+func (g *tsGenerator) arrayType(schema object, indent string) string {
+	items := g.typeOf(schema["items"], indent)
+	if tsIdentifier.MatchString(strings.TrimRight(items, "[]")) {
+		return items + "[]"
+	}
+	return "Array<" + items + ">"
+}
+
+// objectType returns an object type literal for an object schema.
+func (g *tsGenerator) objectType(schema object, indent string) string {
+	props, _ := schema["properties"].(object)
+	if len(props) == 0 {
+		values := "unknown"
+		if patterns, ok := schema["patternProperties"].(object); ok && len(patterns) > 0 {
+			var types []string
+			for _, p := range slices.Sorted(maps.Keys(patterns)) {
+				types = append(types, g.typeOf(patterns[p], indent))
+			}
+			values = strings.Join(slices.Compact(types), " | ")
+		} else if ap, ok := schema["additionalProperties"].(object); ok {
+			values = g.typeOf(ap, indent)
+		}
+		return "Record<string, " + values + ">"
+	}
+	required := requiredSet(schema)
+	var b strings.Builder
+	b.WriteString("{\n")
+	inner := indent + "  "
+	for _, name := range sortedProperties(schema) {
+		prop, _ := props[name].(object)
+		b.WriteString(tsPropertyDoc(name, prop, inner))
+		key := name
+		if !tsIdentifier.MatchString(key) {
+			key = tsLiteral(key)
+		}
+		if !required[name] {
+			key += "?"
+		}
+		fmt.Fprintf(&b, "%s%s: %s;\n", inner, key, g.typeOf(prop, inner))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// method returns the type of a method's entry in the Methods interface.
+func (g *tsGenerator) method(method object) (string, error) {
+	params, _ := method["params"].([]any)
+	// Params can only be optional if all the params after them are.
+	firstOptional := len(params)
+	for firstOptional > 0 {
+		cd, _ := params[firstOptional-1].(object)
+		if required, _ := cd["required"].(bool); required {
+			break
+		}
+		firstOptional--
+	}
+	elems := make([]string, len(params))
+	used := make(map[string]bool)
+	for i, p := range params {
+		cd, _ := p.(object)
+		schema, _ := cd["schema"].(object)
+		expanded, err := g.expand(schema)
+		if err != nil {
+			return "", fmt.Errorf("param %d schema: %w", i, withPointerPrefix(fmt.Sprintf("/params/%d/schema", i), err))
+		}
+		cdName, _ := cd["name"].(string)
+		label := pascalCase(nameWords(cdName), nil)
+		if label == "" || !tsIdentifier.MatchString(label) {
+			label = fmt.Sprintf("param%d", i)
+		}
+		label = strings.ToLower(label[:1]) + label[1:]
+		for tsReserved[label] || used[label] {
+			label += "_"
+		}
+		used[label] = true
+		t := g.typeOf(expanded, "    ")
+		switch required, _ := cd["required"].(bool); {
+		case i >= firstOptional:
+			label += "?"
+		case !required:
+			t = tsParen(t) + " | undefined"
+		}
+		elems[i] = label + ": " + t
+	}
+	result := "null"
+	if r, ok := method["result"].(object); ok {
+		schema, _ := r["schema"].(object)
+		expanded, err := g.expand(schema)
+		if err != nil {
+			return "", fmt.Errorf("result schema: %w", withPointerPrefix("/result/schema", err))
+		}
+		result = g.typeOf(expanded, "    ")
+	}
+	return fmt.Sprintf("{\n    params: [%s];\n    result: %s;\n  }", strings.Join(elems, ", "), result), nil
+}
+
+// tsParen parenthesizes a union or intersection type, for use in another
+// union or an array.
+func tsParen(t string) string {
+	depth := 0
+	for _, r := range t {
+		switch r {
+		case '{', '(', '<', '[':
+			depth++
+		case '}', ')', '>', ']':
+			depth--
+		case '|', '&':
+			if depth == 0 {
+				return "(" + t + ")"
+			}
+		}
+	}
+	return t
+}
+
+func tsLiteral(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "unknown"
+	}
+	return string(b)
+}
+
+// tsDoc returns the doc comment of a declared type.
+func tsDoc(schema object, indent string) string {
+	var parts []string
+	for _, k := range []string{"title", "description"} {
+		if text, ok := schema[k].(string); ok && strings.TrimSpace(text) != "" {
+			parts = append(parts, strings.TrimSpace(text))
+		}
+	}
+	parts = append(parts, tsForkTags(schema)...)
+	if len(parts) == 0 {
+		return ""
+	}
+	return tsComment(strings.Join(parts, "\n\n"), indent)
+}
+
+// tsPropertyDoc returns the doc comment of an object property.
+func tsPropertyDoc(name string, prop object, indent string) string {
+	var parts []string
+	if title, ok := prop["title"].(string); ok && !strings.EqualFold(title, name) {
+		parts = append(parts, title)
+	}
+	parts = append(parts, tsForkTags(prop)...)
+	if len(parts) == 0 {
+		return ""
+	}
+	return tsComment(strings.Join(parts, "\n"), indent)
+}
+
+// tsForkTags returns the JSDoc tags for the deprecation and fork annotations
+// of a definition.
+func tsForkTags(def object) []string {
+	var tags []string
+	if fork, ok := def[sinceForkKey].(string); ok {
+		tags = append(tags, "@since "+fork)
+	}
+	if fork, ok := def[deprecatedKey].(string); ok {
+		tags = append(tags, "@deprecated since "+fork)
+	} else if deprecated, _ := def["deprecated"].(bool); deprecated {
+		tags = append(tags, "@deprecated")
+	}
+	return tags
+}
+
+// tsComment formats text as a JSDoc comment.
+func tsComment(text, indent string) string {
+	text = strings.ReplaceAll(text, "*/", "*\\/")
+	if !strings.Contains(text, "\n") {
+		return indent + "/** " + text + " */\n"
+	}
+	var b strings.Builder
+	b.WriteString(indent + "/**\n")
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+	return b.String()
+}
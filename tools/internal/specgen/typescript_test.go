@@ -0,0 +1,105 @@
+package specgen
+
+import (
+	"strings"
+	"testing"
+)
+
+const tsSchemas = `
+uint:
+  title: hex encoded unsigned integer
+  type: string
+  pattern: ^0x(0|[1-9a-f][0-9a-f]*)$
+Tag:
+  type: string
+  enum: [latest, pending]
+NumberOrTag:
+  oneOf:
+    - $ref: '#/components/schemas/uint'
+    - $ref: '#/components/schemas/Tag'
+Header:
+  type: object
+  required: [number]
+  properties:
+    number:
+      $ref: '#/components/schemas/uint'
+    extra-data:
+      type: string
+Block:
+  title: Block object
+  allOf:
+    - $ref: '#/components/schemas/Header'
+    - type: object
+      properties:
+        transactions:
+          type: array
+          items:
+            oneOf:
+              - $ref: '#/components/schemas/uint'
+              - type: object
+                properties:
+                  hash:
+                    type: string
+`
+
+const tsMethods = `
+- name: eth_getBlock
+  summary: Returns a block.
+  params:
+    - name: Block
+      required: true
+      schema:
+        $ref: '#/components/schemas/NumberOrTag'
+    - name: Full
+      schema:
+        type: boolean
+  result:
+    name: Block
+    schema:
+      oneOf:
+        - type: 'null'
+        - $ref: '#/components/schemas/Block'
+`
+
+func TestTypeScript(t *testing.T) {
+	g := New()
+	if err := g.AddSchemas("schemas.yaml", []byte(tsSchemas)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddMethods("methods.yaml", []byte(tsMethods)); err != nil {
+		t.Fatal(err)
+	}
+	code, err := g.TypeScript()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(code)
+
+	for _, want := range []string{
+		"/** hex encoded unsigned integer */\nexport type Uint = string;\n",
+		`export type Tag = "latest" | "pending";` + "\n",
+		"export type NumberOrTag = Uint | Tag;\n",
+		// allOf is merged, keeping references to other schemas.
+		"/** Block object */\nexport interface Block {\n" +
+			"  \"extra-data\"?: string;\n" +
+			"  number: Uint;\n" +
+			"  transactions?: Array<Uint | {\n" +
+			"    hash?: string;\n" +
+			"  }>;\n" +
+			"}\n",
+		"export interface Header {\n",
+		"  /** Returns a block. */\n" +
+			"  eth_getBlock: {\n" +
+			"    params: [block: NumberOrTag, full?: boolean];\n" +
+			"    result: null | Block;\n" +
+			"  };\n",
+		"export type Result<M extends MethodName> = Methods[M][\"result\"];\n",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code is missing:\n%s", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(src)
+	}
+}
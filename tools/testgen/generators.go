@@ -8,6 +8,7 @@ import (
 	"math/big"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -56,6 +57,20 @@ type Test struct {
 	// checked for spec validity only.
 	SpecOnly bool
 
+	// If ValidParams is true, the request is well-formed although the test name
+	// contains "invalid", and speccheck validates it like any other request.
+	ValidParams bool
+
+	// MutatesState marks tests which modify the state of the client, e.g. by
+	// sending transactions, or which depend on such modifications. They are
+	// filled in order on a client of their own, while the other tests may be
+	// filled in parallel.
+	MutatesState bool
+
+	// Timeout is the time the test may take to fill, for tests which need
+	// longer than usual. If zero, the default timeout of rpctestgen applies.
+	Timeout time.Duration
+
 	Run func(context.Context, *T) error
 }
 
@@ -1765,8 +1780,9 @@ var EthSendRawTransaction = MethodTests{
 	"eth_sendRawTransaction",
 	[]Test{
 		{
-			Name:  "send-legacy-transaction",
-			About: "sends a raw legacy transaction",
+			Name:         "send-legacy-transaction",
+			About:        "sends a raw legacy transaction",
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				sender, nonce := t.chain.GetSender(0)
 				head := t.chain.Head()
@@ -1787,8 +1803,9 @@ var EthSendRawTransaction = MethodTests{
 			},
 		},
 		{
-			Name:  "send-dynamic-fee-transaction",
-			About: "sends a create transaction with dynamic fee",
+			Name:         "send-dynamic-fee-transaction",
+			About:        "sends a create transaction with dynamic fee",
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				sender, nonce := t.chain.GetSender(0)
 				basefee := t.chain.Head().BaseFee()
@@ -1811,8 +1828,9 @@ var EthSendRawTransaction = MethodTests{
 			},
 		},
 		{
-			Name:  "send-access-list-transaction",
-			About: "sends a transaction with access list",
+			Name:         "send-access-list-transaction",
+			About:        "sends a transaction with access list",
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				sender, nonce := t.chain.GetSender(0)
 				basefee := t.chain.Head().BaseFee()
@@ -1836,8 +1854,9 @@ var EthSendRawTransaction = MethodTests{
 			},
 		},
 		{
-			Name:  "send-dynamic-fee-access-list-transaction",
-			About: "sends a transaction with dynamic fee and access list",
+			Name:         "send-dynamic-fee-access-list-transaction",
+			About:        "sends a transaction with dynamic fee and access list",
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				sender, nonce := t.chain.GetSender(0)
 				basefee := t.chain.Head().BaseFee()
@@ -1862,8 +1881,9 @@ var EthSendRawTransaction = MethodTests{
 			},
 		},
 		{
-			Name:  "send-blob-tx",
-			About: "sends a blob transaction",
+			Name:         "send-blob-tx",
+			About:        "sends a blob transaction",
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				var (
 					sender, nonce          = t.chain.GetSender(3)
@@ -2714,8 +2734,9 @@ var TestingBuildBlockV1 = MethodTests{
 	"testing_buildBlockV1",
 	[]Test{
 		{
-			Name:  "build-block-with-transactions",
-			About: "builds a block with specified transactions using testing_buildBlockV1",
+			Name:         "build-block-with-transactions",
+			About:        "builds a block with specified transactions using testing_buildBlockV1",
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				parentBlock := t.chain.Head()
 				parentHash := parentBlock.Hash()
@@ -2803,8 +2824,9 @@ var TestingBuildBlockV1 = MethodTests{
 			},
 		},
 		{
-			Name:  "build-block-empty-transactions",
-			About: "builds a block with empty transactions array using testing_buildBlockV1",
+			Name:         "build-block-empty-transactions",
+			About:        "builds a block with empty transactions array using testing_buildBlockV1",
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				parentBlock := t.chain.Head()
 				parentHash := parentBlock.Hash()
@@ -2856,9 +2878,10 @@ var TestingBuildBlockV1 = MethodTests{
 			},
 		},
 		{
-			Name:     "build-block-from-mempool",
-			About:    "builds a block from mempool using testing_buildBlockV1 with null transactions parameter",
-			SpecOnly: true,
+			Name:         "build-block-from-mempool",
+			About:        "builds a block from mempool using testing_buildBlockV1 with null transactions parameter",
+			SpecOnly:     true,
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				parentBlock := t.chain.Head()
 				parentHash := parentBlock.Hash()
@@ -2939,8 +2962,10 @@ var TestingBuildBlockV1 = MethodTests{
 			},
 		},
 		{
-			Name:  "build-block-invalid-transaction",
-			About: "calls testing_buildBlockV1 with an unapplicable transaction (wrong nonce); client MUST return an error and not modify the chain",
+			Name:         "build-block-invalid-transaction",
+			About:        "calls testing_buildBlockV1 with an unapplicable transaction (wrong nonce); client MUST return an error and not modify the chain",
+			ValidParams:  true,
+			MutatesState: true,
 			Run: func(ctx context.Context, t *T) error {
 				parentBlock := t.chain.Head()
 				parentHash := parentBlock.Hash()
@@ -4865,8 +4890,9 @@ var EthSimulateV1 = MethodTests{
 			},
 		},
 		{
-			Name:  "ethSimulate-check-invalid-nonce",
-			About: "check that nonce cannot decrease",
+			Name:        "ethSimulate-check-invalid-nonce",
+			About:       "check that nonce cannot decrease",
+			ValidParams: true,
 			Run: func(ctx context.Context, t *T) error {
 				params := ethSimulateOpts{
 					BlockStateCalls: []CallBatch{
@@ -6338,8 +6364,9 @@ var EthSimulateV1 = MethodTests{
 			},
 		},
 		{
-			Name:  "ethSimulate-big-block-state-calls-array",
-			About: "Have a block state calls with 300 blocks",
+			Name:    "ethSimulate-big-block-state-calls-array",
+			About:   "Have a block state calls with 300 blocks",
+			Timeout: 10 * time.Second,
 			Run: func(ctx context.Context, t *T) error {
 				calls := make([]CallBatch, 300)
 				params := ethSimulateOpts{BlockStateCalls: calls}
@@ -6946,9 +6973,10 @@ var TxpoolStatus = MethodTests{
 	"txpool_status",
 	[]Test{
 		{
-			Name:     "get-status",
-			About:    "retrieves the transaction pool status",
-			SpecOnly: true,
+			Name:         "get-status",
+			About:        "retrieves the transaction pool status",
+			SpecOnly:     true,
+			MutatesState: true, // reads the pool filled by eth_sendRawTransaction
 			Run: func(ctx context.Context, t *T) error {
 				var result struct {
 					Pending hexutil.Uint `json:"pending"`
@@ -6968,9 +6996,10 @@ var TxpoolContent = MethodTests{
 	"txpool_content",
 	[]Test{
 		{
-			Name:     "get-content",
-			About:    "retrieves the transaction pool content",
-			SpecOnly: true,
+			Name:         "get-content",
+			About:        "retrieves the transaction pool content",
+			SpecOnly:     true,
+			MutatesState: true, // reads the pool filled by eth_sendRawTransaction
 			Run: func(ctx context.Context, t *T) error {
 				var result struct {
 					Pending map[common.Address]map[string]any `json:"pending"`
@@ -6990,9 +7019,10 @@ var TxpoolContentFrom = MethodTests{
 	"txpool_contentFrom",
 	[]Test{
 		{
-			Name:     "get-content-from-address",
-			About:    "retrieves pending transactions from a specific address",
-			SpecOnly: true,
+			Name:         "get-content-from-address",
+			About:        "retrieves pending transactions from a specific address",
+			SpecOnly:     true,
+			MutatesState: true, // reads the pool filled by eth_sendRawTransaction
 			Run: func(ctx context.Context, t *T) error {
 				var result struct {
 					Pending map[string]any `json:"pending"`
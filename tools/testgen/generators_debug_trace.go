@@ -7,6 +7,7 @@ import (
 	"math"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -430,6 +431,7 @@ var DebugTraceBlockByNumber = MethodTests{
 			Name:     "trace-block-with-transactions",
 			About:    "traces a block containing transactions; validates that each entry has txHash and a spec-compliant result",
 			SpecOnly: true,
+			Timeout:  10 * time.Second,
 			Run: func(ctx context.Context, t *T) error {
 				block := t.chain.BlockWithTransactions("", nil)
 				blockNum := hexutil.EncodeUint64(block.NumberU64())
@@ -448,6 +450,7 @@ var DebugTraceBlockByNumber = MethodTests{
 			Name:     "trace-block-memory-encoding",
 			About:    "traces block 0x1 with memory enabled; memory chunks must be 0x-prefixed bytes32 values",
 			SpecOnly: true,
+			Timeout:  10 * time.Second,
 			Run: func(ctx context.Context, t *T) error {
 				traceCfg := map[string]interface{}{
 					"disableStack":     false,
@@ -471,6 +474,7 @@ var DebugTraceBlockByNumber = MethodTests{
 			Name:     "trace-block-storage-encoding",
 			About:    "traces block 0x2 with storage enabled; storage keys and values must be 0x-prefixed bytes32 values",
 			SpecOnly: true,
+			Timeout:  10 * time.Second,
 			Run: func(ctx context.Context, t *T) error {
 				traceCfg := map[string]interface{}{
 					"disableStack":     false,
@@ -494,6 +498,7 @@ var DebugTraceBlockByNumber = MethodTests{
 			Name:     "trace-block-storage-snapshot-timing",
 			About:    "traces block 0x2 and validates cumulative storage snapshots across repeated SSTORE operations",
 			SpecOnly: true,
+			Timeout:  10 * time.Second,
 			Run: func(ctx context.Context, t *T) error {
 				traceCfg := map[string]interface{}{
 					"disableStack":     false,
@@ -523,6 +528,7 @@ var DebugTraceBlockByNumber = MethodTests{
 			Name:     "trace-block-return-data-behavior",
 			About:    "traces a block with returnData disabled and enabled to validate returnData field gating and encoding",
 			SpecOnly: true,
+			Timeout:  10 * time.Second,
 			Run: func(ctx context.Context, t *T) error {
 				blockNum := hexutil.EncodeUint64(1)
 
@@ -607,6 +613,7 @@ var DebugTraceBlockByHash = MethodTests{
 			Name:     "trace-block-with-transactions",
 			About:    "traces a block containing transactions by hash; validates that each entry has txHash and a spec-compliant result",
 			SpecOnly: true,
+			Timeout:  10 * time.Second,
 			Run: func(ctx context.Context, t *T) error {
 				block := t.chain.BlockWithTransactions("", nil)
 				var result []map[string]interface{}